@@ -0,0 +1,299 @@
+// Command deploy drives the stacks of this project through the Pulumi Automation API. It previews, updates,
+// destroys, refreshes and lists the stacks of an environment, streams the engine events as JSON lines and
+// prints a JSON summary of the changes for CI.
+//
+//	go run ./cmd/deploy -env dev preview
+//	go run ./cmd/deploy -env dev -expect-no-changes preview
+//	go run ./cmd/deploy -env dev refresh
+//	go run ./cmd/deploy list
+//	go run ./cmd/deploy -env dev promote
+//	go run ./cmd/deploy -env dev -yes destroy
+//
+// Without -env the stack selected in the workspace is used. destroy asks for the stack name on stdin before deleting
+// anything, -yes skips the prompt.
+//
+// promote makes the idle fleet of a blue/green stack live, takes the old fleet out of rotation and runs up. Running it
+// again rolls back. When up fails the blue/green config of the stack is restored.
+//
+// The exit code is 0 on success, 1 when the command fails, 2 on mandatory policy violations and 3 when drift
+// is detected: a refresh that changed the state, or changes in a preview or up run with -expect-no-changes.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+)
+
+const projectName = "iac-pulumi"
+
+// configKey is the config object of the program, see Data in the main package
+const configKey = "config"
+
+type options struct {
+	env             string
+	org             string
+	workDir         string
+	eventsPath      string
+	summaryPath     string
+	expectNoChanges bool
+	progress        bool
+	yes             bool
+	policyPacks     stringList
+}
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// previewPolicyPacks and upPolicyPacks run local policy packs, the automation API of our SDK version has the
+// option fields but no constructor for them.
+type previewPolicyPacks []string
+
+func (p previewPolicyPacks) ApplyOption(opts *optpreview.Options) {
+	opts.PolicyPacks = append(opts.PolicyPacks, p...)
+}
+
+type upPolicyPacks []string
+
+func (p upPolicyPacks) ApplyOption(opts *optup.Options) {
+	opts.PolicyPacks = append(opts.PolicyPacks, p...)
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.env, "env", "", "environment to operate on, the stack name (e.g. dev or demo), defaults to the selected stack")
+	flag.StringVar(&opts.org, "org", "", "organization of the stack, defaults to the default organization of the backend")
+	flag.StringVar(&opts.workDir, "work-dir", ".", "directory of the Pulumi project")
+	flag.StringVar(&opts.eventsPath, "events", "-", "file the engine events are streamed to as JSON lines, - for stderr")
+	flag.StringVar(&opts.summaryPath, "summary", "-", "file the JSON summary is written to, - for stdout")
+	flag.BoolVar(&opts.expectNoChanges, "expect-no-changes", false, "report drift when preview or up has changes")
+	flag.BoolVar(&opts.progress, "progress", false, "stream the human readable progress to stderr")
+	flag.BoolVar(&opts.yes, "yes", false, "destroy without asking for confirmation")
+	flag.Var(&opts.policyPacks, "policy-pack", "path of a local policy pack to enforce on preview and up, can be repeated")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] preview|up|promote|destroy|refresh|list\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(exitError)
+	}
+
+	// Cancel the running operation on Ctrl-C so the stack isn't left locked
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	summary := newSummary(flag.Arg(0))
+	summary.Finish(run(ctx, summary, opts))
+
+	if err := writeSummary(summary, opts.summaryPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
+	}
+
+	os.Exit(summary.ExitCode())
+}
+
+func run(ctx context.Context, summary *Summary, opts options) error {
+	if summary.Command == "list" {
+		workspace, err := auto.NewLocalWorkspace(ctx, auto.WorkDir(opts.workDir))
+		if err != nil {
+			return err
+		}
+
+		summary.Stacks, err = workspace.ListStacks(ctx)
+		return err
+	}
+
+	summary.Stack = opts.env
+	if opts.org != "" {
+		summary.Stack = auto.FullyQualifiedStackName(opts.org, projectName, opts.env)
+	}
+
+	// Like the pulumi CLI, act on the selected stack when no environment is given
+	if opts.env == "" {
+		workspace, err := auto.NewLocalWorkspace(ctx, auto.WorkDir(opts.workDir))
+		if err != nil {
+			return err
+		}
+
+		current, err := workspace.Stack(ctx)
+		if err != nil {
+			return err
+		}
+
+		if current == nil {
+			return fmt.Errorf("no stack is selected, pass -env or run make select s=<stack> for %s", summary.Command)
+		}
+
+		summary.Stack = current.Name
+	}
+
+	if summary.Command == "destroy" && !opts.yes {
+		if err := confirmDestroy(summary.Stack, os.Stdin, os.Stderr); err != nil {
+			return err
+		}
+	}
+
+	stack, err := auto.SelectStackLocalSource(ctx, summary.Stack, opts.workDir)
+	if err != nil {
+		return err
+	}
+
+	eventsWriter := io.Writer(os.Stderr)
+	if opts.eventsPath != "-" {
+		eventsFile, err := os.Create(opts.eventsPath)
+		if err != nil {
+			return err
+		}
+		defer eventsFile.Close()
+
+		eventsWriter = eventsFile
+	}
+
+	var progressStreams []io.Writer
+	if opts.progress {
+		progressStreams = append(progressStreams, os.Stderr)
+	}
+
+	engineEvents := make(chan events.EngineEvent)
+	eventsDone := make(chan struct{})
+	go func() {
+		collectEvents(engineEvents, eventsWriter, summary)
+		close(eventsDone)
+	}()
+
+	// up is shared by up and promote, which switches the live fleet first and runs it
+	up := func() error {
+		result, err := stack.Up(ctx, optup.EventStreams(engineEvents), optup.ProgressStreams(progressStreams...),
+			upPolicyPacks(opts.policyPacks))
+
+		summary.SetChanges(resourceChanges(result.Summary))
+		summary.Drift = opts.expectNoChanges && summary.HasChanges()
+
+		// Secret outputs stay masked in the summary, CI logs are not a safe place for them
+		summary.Outputs = make(map[string]interface{}, len(result.Outputs))
+		for name, output := range result.Outputs {
+			summary.Outputs[name] = output.Value
+			if output.Secret {
+				summary.Outputs[name] = "[secret]"
+			}
+		}
+
+		return err
+	}
+
+	switch summary.Command {
+	case "preview":
+		var result auto.PreviewResult
+		result, err = stack.Preview(ctx, optpreview.EventStreams(engineEvents), optpreview.ProgressStreams(progressStreams...),
+			previewPolicyPacks(opts.policyPacks))
+
+		changes := make(map[string]int, len(result.ChangeSummary))
+		for op, count := range result.ChangeSummary {
+			changes[string(op)] = count
+		}
+
+		summary.SetChanges(changes)
+		summary.Drift = opts.expectNoChanges && summary.HasChanges()
+	case "promote":
+		upStarted := false
+		summary.Promoted, err = promote(ctx, &stack, func() error {
+			upStarted = true
+			return up()
+		})
+
+		// The automation API only closes the event channel of an operation it ran
+		if !upStarted {
+			close(engineEvents)
+		}
+	case "up":
+		err = up()
+	case "destroy":
+		var result auto.DestroyResult
+		result, err = stack.Destroy(ctx, optdestroy.EventStreams(engineEvents), optdestroy.ProgressStreams(progressStreams...))
+
+		summary.SetChanges(resourceChanges(result.Summary))
+	case "refresh":
+		var result auto.RefreshResult
+		result, err = stack.Refresh(ctx, optrefresh.EventStreams(engineEvents), optrefresh.ProgressStreams(progressStreams...))
+
+		// Anything the refresh had to change in the state drifted from what was last deployed
+		summary.SetChanges(resourceChanges(result.Summary))
+		summary.Drift = summary.HasChanges()
+	default:
+		close(engineEvents)
+		err = fmt.Errorf("unknown command %q, expected preview, up, promote, destroy, refresh or list", summary.Command)
+	}
+
+	// The automation API closes the event channel when the operation ends, unless it failed before starting it
+	if err == nil || !strings.Contains(err.Error(), "failed to tail logs") {
+		<-eventsDone
+	}
+
+	return err
+}
+
+// confirmDestroy asks for the stack name before destroy deletes every resource of the stack.
+func confirmDestroy(stack string, in io.Reader, out io.Writer) error {
+	fmt.Fprintf(out, "destroy deletes every resource of the stack %s, type the stack name to confirm: ", stack)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if strings.TrimSpace(answer) != stack {
+		return fmt.Errorf("destroy of %s was not confirmed, pass -yes to skip the prompt", stack)
+	}
+
+	return nil
+}
+
+func resourceChanges(summary auto.UpdateSummary) map[string]int {
+	if summary.ResourceChanges == nil {
+		return nil
+	}
+
+	return *summary.ResourceChanges
+}
+
+func writeSummary(summary *Summary, path string) error {
+	output, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	output = append(output, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(output)
+		return err
+	}
+
+	return os.WriteFile(path, output, 0o644)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirmDestroy(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "stack name", input: "dev\n"},
+		{name: "stack name without newline", input: "dev"},
+		{name: "other stack", input: "demo\n", wantErr: true},
+		{name: "yes", input: "y\n", wantErr: true},
+		{name: "no input", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt bytes.Buffer
+			err := confirmDestroy("dev", strings.NewReader(tt.input), &prompt)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("confirmDestroy() = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !strings.Contains(prompt.String(), "dev") {
+				t.Errorf("prompt %q doesn't name the stack", prompt.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/deployment"
+)
+
+// configStack is the part of a stack promote uses, *auto.Stack implements it.
+type configStack interface {
+	Name() string
+	GetConfig(ctx context.Context, key string) (auto.ConfigValue, error)
+	SetAllConfigWithOptions(ctx context.Context, config auto.ConfigMap, opts *auto.ConfigOptions) error
+}
+
+// promote switches the live color in the config of the stack and runs up, which shifts the requests. When up fails
+// the previous blue/green config is written back, so that the config keeps describing what is deployed.
+func promote(ctx context.Context, stack configStack, up func() error) (string, error) {
+	value, err := stack.GetConfig(ctx, configKey)
+	if err != nil {
+		return "", err
+	}
+
+	var configData struct {
+		BlueGreen deployment.BlueGreen `json:"blue_green"`
+	}
+	if err = json.Unmarshal([]byte(value.Value), &configData); err != nil {
+		return "", fmt.Errorf("reading %s: %w", configKey, err)
+	}
+
+	previous := configData.BlueGreen
+	if !previous.Enabled {
+		return "", fmt.Errorf("blue_green is not enabled in the config of %s", stack.Name())
+	}
+
+	promoted := previous.Promote()
+	if err = setBlueGreen(ctx, stack, promoted); err != nil {
+		return "", err
+	}
+
+	if err = up(); err != nil {
+		if restoreErr := setBlueGreen(ctx, stack, previous); restoreErr != nil {
+			return "", fmt.Errorf("%w; restoring blue_green.live %s and canary_weight %d failed: %v", err, previous.LiveColor(), previous.CanaryWeight, restoreErr)
+		}
+
+		return "", err
+	}
+
+	return promoted.Live, nil
+}
+
+func setBlueGreen(ctx context.Context, stack configStack, blueGreen deployment.BlueGreen) error {
+	return stack.SetAllConfigWithOptions(ctx, auto.ConfigMap{
+		configKey + ".blue_green.live":          auto.ConfigValue{Value: blueGreen.LiveColor()},
+		configKey + ".blue_green.canary_weight": auto.ConfigValue{Value: strconv.Itoa(blueGreen.CanaryWeight)},
+	}, &auto.ConfigOptions{Path: true})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// fakeStack keeps the blue/green values promote writes, with the config object it started from.
+type fakeStack struct {
+	config string
+	values map[string]string
+}
+
+func (s *fakeStack) Name() string {
+	return "dev"
+}
+
+func (s *fakeStack) GetConfig(ctx context.Context, key string) (auto.ConfigValue, error) {
+	return auto.ConfigValue{Value: s.config}, nil
+}
+
+func (s *fakeStack) SetAllConfigWithOptions(ctx context.Context, config auto.ConfigMap, opts *auto.ConfigOptions) error {
+	if opts == nil || !opts.Path {
+		return errors.New("blue/green values must be set by path")
+	}
+
+	for key, value := range config {
+		s.values[key] = value.Value
+	}
+
+	return nil
+}
+
+func TestPromote(t *testing.T) {
+	tests := []struct {
+		name         string
+		upErr        error
+		wantPromoted string
+		wantLive     string
+		wantCanary   string
+	}{
+		{name: "up succeeds", wantPromoted: "blue", wantLive: "blue", wantCanary: "0"},
+		{name: "up fails", upErr: errors.New("update failed"), wantLive: "green", wantCanary: "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := &fakeStack{
+				config: `{"blue_green": {"enabled": true, "live": "green", "canary_weight": 10}}`,
+				values: map[string]string{},
+			}
+
+			var liveDuringUp string
+			promoted, err := promote(context.Background(), stack, func() error {
+				liveDuringUp = stack.values["config.blue_green.live"]
+				return tt.upErr
+			})
+			if !errors.Is(err, tt.upErr) || (tt.upErr == nil && err != nil) {
+				t.Fatalf("promote() = %v, want %v", err, tt.upErr)
+			}
+
+			if liveDuringUp != "blue" {
+				t.Errorf("up ran with live %q, want the promoted blue fleet", liveDuringUp)
+			}
+
+			if promoted != tt.wantPromoted {
+				t.Errorf("promoted = %q, want %q", promoted, tt.wantPromoted)
+			}
+
+			if live, canary := stack.values["config.blue_green.live"], stack.values["config.blue_green.canary_weight"]; live != tt.wantLive || canary != tt.wantCanary {
+				t.Errorf("config left with live %s and canary_weight %s, want %s and %s", live, canary, tt.wantLive, tt.wantCanary)
+			}
+		})
+	}
+}
+
+func TestPromoteWithoutBlueGreen(t *testing.T) {
+	stack := &fakeStack{config: `{"blue_green": {"enabled": false}}`, values: map[string]string{}}
+
+	_, err := promote(context.Background(), stack, func() error {
+		t.Fatal("up ran for a stack without blue/green")
+		return nil
+	})
+	if err == nil || len(stack.values) != 0 {
+		t.Errorf("promote() = %v and set %v, want an error and no config changes", err, stack.values)
+	}
+}
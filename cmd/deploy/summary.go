@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+)
+
+const (
+	exitOK              = 0
+	exitError           = 1
+	exitPolicyViolation = 2
+	exitDrift           = 3
+)
+
+const (
+	ResultSucceeded       = "succeeded"
+	ResultFailed          = "failed"
+	ResultPolicyViolation = "policy-violation"
+	ResultDrift           = "drift"
+)
+
+type PolicyViolation struct {
+	PolicyPack       string `json:"policyPack"`
+	Policy           string `json:"policy"`
+	Resource         string `json:"resource,omitempty"`
+	EnforcementLevel string `json:"enforcementLevel"`
+	Message          string `json:"message"`
+}
+
+// Summary is the JSON document printed at the end of every command for CI to consume.
+type Summary struct {
+	Command          string                 `json:"command"`
+	Stack            string                 `json:"stack,omitempty"`
+	Result           string                 `json:"result"`
+	Changes          map[string]int         `json:"changes,omitempty"`
+	Drift            bool                   `json:"drift"`
+	PolicyViolations []PolicyViolation      `json:"policyViolations,omitempty"`
+	Diagnostics      []string               `json:"diagnostics,omitempty"`
+	Outputs          map[string]interface{} `json:"outputs,omitempty"`
+	Stacks           []auto.StackSummary    `json:"stacks,omitempty"`
+	Promoted         string                 `json:"promoted,omitempty"`
+	Error            string                 `json:"error,omitempty"`
+	StartTime        time.Time              `json:"startTime"`
+	DurationSeconds  float64                `json:"durationSeconds"`
+
+	mu sync.Mutex
+}
+
+func newSummary(command string) *Summary {
+	return &Summary{Command: command, StartTime: time.Now().UTC()}
+}
+
+// SetChanges records the resource operation counts, any operation other than leaving a resource as it is
+// counts as a change.
+func (s *Summary) SetChanges(changes map[string]int) {
+	s.Changes = changes
+}
+
+func (s *Summary) HasChanges() bool {
+	for op, count := range s.Changes {
+		switch op {
+		case "same", "read", "refresh":
+		default:
+			if count > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HasMandatoryPolicyViolations reports whether a policy that blocks the deployment was violated.
+func (s *Summary) HasMandatoryPolicyViolations() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, violation := range s.PolicyViolations {
+		if violation.EnforcementLevel == "mandatory" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Finish fills in the result and the duration of the command from what was recorded.
+func (s *Summary) Finish(err error) {
+	if err != nil {
+		s.Error = err.Error()
+	}
+
+	switch {
+	case s.HasMandatoryPolicyViolations():
+		s.Result = ResultPolicyViolation
+	case s.Error != "":
+		s.Result = ResultFailed
+	case s.Drift:
+		s.Result = ResultDrift
+	default:
+		s.Result = ResultSucceeded
+	}
+
+	s.DurationSeconds = time.Since(s.StartTime).Seconds()
+}
+
+func (s *Summary) ExitCode() int {
+	switch s.Result {
+	case ResultSucceeded:
+		return exitOK
+	case ResultPolicyViolation:
+		return exitPolicyViolation
+	case ResultDrift:
+		return exitDrift
+	default:
+		return exitError
+	}
+}
+
+// collectEvents writes every engine event as a JSON line to w and records the policy violations and error
+// diagnostics in the summary. It returns once the channel is closed by the automation API.
+func collectEvents(engineEvents <-chan events.EngineEvent, w io.Writer, summary *Summary) {
+	encoder := json.NewEncoder(w)
+
+	for event := range engineEvents {
+		if event.Error != nil {
+			_ = encoder.Encode(map[string]string{"error": event.Error.Error()})
+			continue
+		}
+
+		_ = encoder.Encode(event.EngineEvent)
+
+		summary.mu.Lock()
+		if policyEvent := event.PolicyEvent; policyEvent != nil {
+			summary.PolicyViolations = append(summary.PolicyViolations, PolicyViolation{
+				PolicyPack:       policyEvent.PolicyPackName,
+				Policy:           policyEvent.PolicyName,
+				Resource:         policyEvent.ResourceURN,
+				EnforcementLevel: policyEvent.EnforcementLevel,
+				Message:          policyEvent.Message,
+			})
+		}
+
+		if diagnosticEvent := event.DiagnosticEvent; diagnosticEvent != nil && diagnosticEvent.Severity == "error" {
+			summary.Diagnostics = append(summary.Diagnostics, diagnosticEvent.Message)
+		}
+		summary.mu.Unlock()
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+func TestSummaryExitCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		changes    map[string]int
+		drift      bool
+		violations []PolicyViolation
+		err        error
+		wantResult string
+		wantCode   int
+	}{
+		{
+			name:       "no changes",
+			changes:    map[string]int{"same": 12},
+			wantResult: ResultSucceeded,
+			wantCode:   exitOK,
+		},
+		{
+			name:       "failed update",
+			err:        errors.New("update failed"),
+			wantResult: ResultFailed,
+			wantCode:   exitError,
+		},
+		{
+			name:       "drift",
+			changes:    map[string]int{"same": 10, "update": 2},
+			drift:      true,
+			wantResult: ResultDrift,
+			wantCode:   exitDrift,
+		},
+		{
+			name:       "advisory policy violations don't fail",
+			violations: []PolicyViolation{{Policy: "tags", EnforcementLevel: "advisory"}},
+			wantResult: ResultSucceeded,
+			wantCode:   exitOK,
+		},
+		{
+			name:       "mandatory policy violation wins over the error it causes",
+			violations: []PolicyViolation{{Policy: "tags", EnforcementLevel: "mandatory"}},
+			err:        errors.New("preview failed"),
+			wantResult: ResultPolicyViolation,
+			wantCode:   exitPolicyViolation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := newSummary("preview")
+			summary.SetChanges(tt.changes)
+			summary.Drift = tt.drift
+			summary.PolicyViolations = tt.violations
+			summary.Finish(tt.err)
+
+			if summary.Result != tt.wantResult || summary.ExitCode() != tt.wantCode {
+				t.Errorf("got result %q and exit code %d, want %q and %d", summary.Result, summary.ExitCode(), tt.wantResult, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSummaryHasChanges(t *testing.T) {
+	tests := []struct {
+		changes map[string]int
+		want    bool
+	}{
+		{changes: nil, want: false},
+		{changes: map[string]int{"same": 3, "read": 1, "refresh": 3}, want: false},
+		{changes: map[string]int{"same": 3, "create": 0}, want: false},
+		{changes: map[string]int{"same": 3, "delete": 1}, want: true},
+		{changes: map[string]int{"replace": 1}, want: true},
+	}
+
+	for _, tt := range tests {
+		summary := newSummary("refresh")
+		summary.SetChanges(tt.changes)
+
+		if got := summary.HasChanges(); got != tt.want {
+			t.Errorf("HasChanges() with %v = %v, want %v", tt.changes, got, tt.want)
+		}
+	}
+}
+
+func TestCollectEvents(t *testing.T) {
+	engineEvents := make(chan events.EngineEvent, 3)
+	engineEvents <- events.EngineEvent{EngineEvent: apitype.EngineEvent{PolicyEvent: &apitype.PolicyEvent{
+		PolicyPackName:   "aws-baseline",
+		PolicyName:       "s3-no-public-read",
+		ResourceURN:      "urn:pulumi:dev::iac-pulumi::aws:s3/bucket:Bucket::logs",
+		EnforcementLevel: "mandatory",
+		Message:          "bucket is public",
+	}}}
+	engineEvents <- events.EngineEvent{EngineEvent: apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+		Severity: "error",
+		Message:  "creating ALB failed",
+	}}}
+	engineEvents <- events.EngineEvent{EngineEvent: apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+		Severity: "info",
+		Message:  "just information",
+	}}}
+	close(engineEvents)
+
+	var output bytes.Buffer
+	summary := newSummary("up")
+	collectEvents(engineEvents, &output, summary)
+
+	if lines := strings.Count(output.String(), "\n"); lines != 3 {
+		t.Errorf("got %d event lines, want 3", lines)
+	}
+
+	if len(summary.PolicyViolations) != 1 || !summary.HasMandatoryPolicyViolations() {
+		t.Errorf("got policy violations %v, want the mandatory violation", summary.PolicyViolations)
+	}
+
+	if len(summary.Diagnostics) != 1 || summary.Diagnostics[0] != "creating ALB failed" {
+		t.Errorf("got diagnostics %v, want only the error", summary.Diagnostics)
+	}
+}
@@ -0,0 +1,201 @@
+package apptier
+
+import (
+	"os"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/deployment"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/encryption"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+type EC2Instance struct {
+	InstanceName             string `json:"instance_name,omitempty"`
+	InstanceType             string `json:"instance_type,omitempty"`
+	VolumeSize               int    `json:"volume_size,omitempty"`
+	VolumeType               string `json:"volume_type,omitempty"`
+	DeleteOnTermination      bool   `json:"delete_on_termination,omitempty"`
+	DisableApiTermination    bool   `json:"disable_api_termination,omitempty"`
+	AssociatePublicIpAddress bool   `json:"associate_public_ip,omitempty"`
+	DeviceType               string `json:"device_type,omitempty"`
+	AmiID                    string `json:"ami_id,omitempty"`
+	SSHKeyName               string `json:"ssh_key_name,omitempty"`
+	LogFilePath              string `json:"log_file_path,omitempty"`
+	MetricServerPort         int    `json:"metric_server_port,omitempty"`
+	UserDataFilePath         string `json:"users_data_file_path,omitempty"`
+	MigrationsFilePath       string `json:"migrations_file_path,omitempty"`
+	PublicKeyFilePath        string `json:"public_key_file_path,omitempty"`
+}
+
+type LogGroup struct {
+	Name            string `json:"name"`
+	RetentionInDays int    `json:"retention_in_days,omitempty"`
+}
+
+type AppTierArgs struct {
+	Instance            EC2Instance
+	LogGroups           []LogGroup
+	UserData            pulumi.StringInput
+	InstanceProfileName pulumi.StringInput
+	SecurityGroupID     pulumi.IDOutput
+	SubnetIDs           pulumi.StringArrayInput
+	// TargetGroupArns holds the app target group followed by the configured target groups
+	TargetGroupArns pulumi.StringArrayInput
+	// GreenTargetGroupArn is the app target group of the green fleet when blue/green is enabled
+	GreenTargetGroupArn pulumi.StringInput
+	// LoadBalancerArnSuffix and the target group ARN suffixes identify them in CloudWatch metrics
+	LoadBalancerArnSuffix     pulumi.StringInput
+	TargetGroupArnSuffix      pulumi.StringInput
+	GreenTargetGroupArnSuffix pulumi.StringInput
+	BlueGreen                 deployment.BlueGreen
+	AutoScaling               AutoScaling
+	EbsKmsKey                 *kms.Key
+	LogsKmsKey                *kms.Key
+	Namer                     *naming.Namer
+}
+
+// AppTier runs the app on an autoscaling group of instances launched from a launch template, scaled by the
+// configured policies and registered with the load balancer target group.
+type AppTier struct {
+	pulumi.ResourceState
+
+	// AutoScalingGroupName and LaunchTemplateID are those of the live fleet when blue/green is enabled
+	AutoScalingGroupName pulumi.StringOutput
+	LaunchTemplateID     pulumi.IDOutput
+	// AutoScalingGroupNames holds the autoscaling group of each color when blue/green is enabled
+	AutoScalingGroupNames pulumi.StringMapOutput
+}
+
+// fleetTargetGroupArns attaches each fleet to the app target group of its color, the configured target groups have
+// no weights and are only served by the live fleet.
+func fleetTargetGroupArns(targetGroupArns pulumi.StringArrayOutput, greenTargetGroupArn pulumi.StringOutput, live string) map[string]pulumi.StringArrayOutput {
+	blue := targetGroupArns.ApplyT(func(arns []string) []string {
+		if live == deployment.Blue {
+			return arns
+		}
+
+		return arns[:1]
+	}).(pulumi.StringArrayOutput)
+
+	green := pulumi.All(targetGroupArns, greenTargetGroupArn).ApplyT(func(values []interface{}) []string {
+		arns := []string{values[1].(string)}
+		if live == deployment.Green {
+			arns = append(arns, values[0].([]string)[1:]...)
+		}
+
+		return arns
+	}).(pulumi.StringArrayOutput)
+
+	return map[string]pulumi.StringArrayOutput{deployment.Blue: blue, deployment.Green: green}
+}
+
+func NewAppTier(ctx *pulumi.Context, name string, args *AppTierArgs, opts ...pulumi.ResourceOption) (*AppTier, error) {
+	appTier := &AppTier{}
+	err := ctx.RegisterComponentResource("webapp:apptier:AppTier", name, appTier, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources were created at the top level of the stack before they were grouped, keep their URNs
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(appTier), pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}})}
+	newChildOpts := []pulumi.ResourceOption{pulumi.Parent(appTier)}
+
+	SetAutoScalingDefaults(&args.AutoScaling)
+	if err = args.AutoScaling.Validate(); err != nil {
+		return nil, err
+	}
+
+	instance := args.Instance
+
+	// Create the CloudWatch log groups the app and the CloudWatch agent write to, encrypted with the logs key
+	for _, logGroup := range args.LogGroups {
+		_, err = cloudwatch.NewLogGroup(ctx, logGroup.Name, &cloudwatch.LogGroupArgs{
+			Name:            pulumi.String(logGroup.Name),
+			RetentionInDays: pulumi.Int(logGroup.RetentionInDays),
+			KmsKeyId:        encryption.KMSKeyArn(args.LogsKmsKey),
+			Tags: pulumi.StringMap{
+				"Name": pulumi.String(logGroup.Name),
+			},
+		}, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the public key content from the file.
+	publicKeyContent, err := os.ReadFile(instance.PublicKeyFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create an EC2 key pair.
+	keyName := args.Namer.Existing(naming.KeyPair, instance.SSHKeyName, instance.SSHKeyName)
+	_, err = ec2.NewKeyPair(ctx, instance.SSHKeyName, &ec2.KeyPairArgs{
+		KeyName:   pulumi.String(keyName),
+		PublicKey: pulumi.String(publicKeyContent),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blue/green runs a second fleet, the fleet that is not live only serves the app target group of its color
+	if !args.BlueGreen.Enabled {
+		autoscalingGroup, launchTemplate, err := newFleet(ctx, args, fleet{name: "app", amiID: instance.AmiID, targetGroupArns: args.TargetGroupArns,
+			targetGroupArnSuffix: args.TargetGroupArnSuffix}, keyName, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		appTier.AutoScalingGroupName = autoscalingGroup.Name
+		appTier.LaunchTemplateID = launchTemplate.ID()
+	} else {
+		blueGreen := args.BlueGreen
+		targetGroupArns := fleetTargetGroupArns(args.TargetGroupArns.ToStringArrayOutput(), args.GreenTargetGroupArn.ToStringOutput(), blueGreen.LiveColor())
+
+		fleets := map[string]fleet{
+			deployment.Blue: {name: "app", amiID: blueGreen.AmiID(deployment.Blue, instance.AmiID), targetGroupArns: targetGroupArns[deployment.Blue],
+				targetGroupArnSuffix: args.TargetGroupArnSuffix},
+			deployment.Green: {name: "app-green", logicalSuffix: "_green", amiID: blueGreen.AmiID(deployment.Green, instance.AmiID),
+				targetGroupArns: targetGroupArns[deployment.Green], targetGroupArnSuffix: args.GreenTargetGroupArnSuffix},
+		}
+
+		autoScalingGroupNames := pulumi.StringMap{}
+		for _, color := range []string{deployment.Blue, deployment.Green} {
+			fleetOpts := childOpts
+			if color == deployment.Green {
+				fleetOpts = newChildOpts
+			}
+
+			autoscalingGroup, launchTemplate, err := newFleet(ctx, args, fleets[color], keyName, fleetOpts...)
+			if err != nil {
+				return nil, err
+			}
+
+			autoScalingGroupNames[color] = autoscalingGroup.Name
+			if color == blueGreen.LiveColor() {
+				appTier.AutoScalingGroupName = autoscalingGroup.Name
+				appTier.LaunchTemplateID = launchTemplate.ID()
+			}
+		}
+
+		appTier.AutoScalingGroupNames = autoScalingGroupNames.ToStringMapOutput()
+	}
+
+	outputs := pulumi.Map{
+		"autoScalingGroupName": appTier.AutoScalingGroupName,
+		"launchTemplateId":     appTier.LaunchTemplateID,
+	}
+	if args.BlueGreen.Enabled {
+		outputs["autoScalingGroupNames"] = appTier.AutoScalingGroupNames
+	}
+
+	err = ctx.RegisterResourceOutputs(appTier, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return appTier, nil
+}
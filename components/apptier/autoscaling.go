@@ -0,0 +1,193 @@
+package apptier
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/autoscaling"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type AutoScaling struct {
+	// MinSize and DesiredCapacity are 1 and MaxSize is 3 when not set
+	MinSize         *int `json:"min_size,omitempty"`
+	MaxSize         int  `json:"max_size,omitempty"`
+	DesiredCapacity *int `json:"desired_capacity,omitempty"`
+	// DefaultCooldown is 60 and HealthCheckGracePeriod 300 seconds when not set
+	DefaultCooldown        *int `json:"default_cooldown,omitempty"`
+	HealthCheckGracePeriod *int `json:"health_check_grace_period,omitempty"`
+	// HealthCheckType is ELB when not set, so instances failing the target group health check are replaced
+	HealthCheckType     string   `json:"health_check_type,omitempty"`
+	TerminationPolicies []string `json:"termination_policies,omitempty"`
+	// CapacityRebalance replaces Spot instances at an elevated risk of interruption before they are interrupted
+	CapacityRebalance bool `json:"capacity_rebalance,omitempty"`
+	// MaxInstanceLifetime is the seconds after which an instance is replaced, 0 keeps instances until they fail
+	MaxInstanceLifetime int             `json:"max_instance_lifetime,omitempty"`
+	InstanceRefresh     InstanceRefresh `json:"instance_refresh,omitempty"`
+	// Scaling holds the scaling policies of the group, DefaultScaling when none are set
+	Scaling Scaling `json:"scaling,omitempty"`
+}
+
+var terminationPolicies = []string{"Default", "AllocationStrategy", "OldestLaunchTemplate", "OldestLaunchConfiguration",
+	"ClosestToNextInstanceHour", "NewestInstance", "OldestInstance"}
+
+// SetAutoScalingDefaults fills in the capacity and health checks the group had before they were configurable, except
+// for the health check type which is now ELB.
+func SetAutoScalingDefaults(config *AutoScaling) {
+	if config.MinSize == nil {
+		config.MinSize = intPtr(1)
+	}
+
+	if config.DesiredCapacity == nil {
+		config.DesiredCapacity = intPtr(1)
+	}
+
+	if config.DefaultCooldown == nil {
+		config.DefaultCooldown = intPtr(60)
+	}
+
+	if config.HealthCheckGracePeriod == nil {
+		config.HealthCheckGracePeriod = intPtr(300)
+	}
+
+	if config.MaxSize == 0 {
+		config.MaxSize = 3
+	}
+
+	if config.HealthCheckType == "" {
+		config.HealthCheckType = "ELB"
+	}
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+// Validate checks a config that has its defaults set.
+func (c AutoScaling) Validate() error {
+	if *c.MinSize < 0 || *c.MinSize > c.MaxSize || *c.DesiredCapacity < *c.MinSize || *c.DesiredCapacity > c.MaxSize {
+		return fmt.Errorf(`{"status": 400, "msg": "Autoscaling capacity must be 0 <= min_size %d <= desired_capacity %d <= max_size %d."}`, *c.MinSize, *c.DesiredCapacity, c.MaxSize)
+	}
+
+	if *c.DefaultCooldown < 0 || *c.HealthCheckGracePeriod < 0 {
+		return fmt.Errorf(`{"status": 400, "msg": "Autoscaling default_cooldown %d and health_check_grace_period %d can't be negative."}`, *c.DefaultCooldown, *c.HealthCheckGracePeriod)
+	}
+
+	if c.HealthCheckType != "EC2" && c.HealthCheckType != "ELB" {
+		return fmt.Errorf(`{"status": 400, "msg": "Incorrect param health_check_type %s, expected EC2 or ELB."}`, c.HealthCheckType)
+	}
+
+	for _, policy := range c.TerminationPolicies {
+		if !slices.Contains(terminationPolicies, policy) {
+			return fmt.Errorf(`{"status": 400, "msg": "Unsupported termination policy %s, expected one of %v."}`, policy, terminationPolicies)
+		}
+	}
+
+	if c.MaxInstanceLifetime != 0 && (c.MaxInstanceLifetime < 86400 || c.MaxInstanceLifetime > 31536000) {
+		return fmt.Errorf(`{"status": 400, "msg": "Incorrect param max_instance_lifetime %d, it must be 0 or between 1 and 365 days in seconds."}`, c.MaxInstanceLifetime)
+	}
+
+	if err := c.InstanceRefresh.Validate(); err != nil {
+		return err
+	}
+
+	return c.Scaling.Validate()
+}
+
+// InstanceRefresh replaces the instances of the autoscaling group when its launch template changes, which is every
+// time the AMI or the user data change.
+type InstanceRefresh struct {
+	// Enabled is true when not set
+	Enabled *bool `json:"enabled,omitempty"`
+	// MinHealthyPercentage is the share of the capacity kept in service during the refresh, 90 when not set
+	MinHealthyPercentage *int `json:"min_healthy_percentage,omitempty"`
+	// InstanceWarmup is the seconds a new instance gets before it counts as healthy, the health check grace period
+	// of the group when not set
+	InstanceWarmup *int `json:"instance_warmup,omitempty"`
+	// CheckpointPercentages pause the refresh for CheckpointDelay seconds once that share of the instances is replaced,
+	// the last one is 100
+	CheckpointPercentages []int `json:"checkpoint_percentages,omitempty"`
+	CheckpointDelay       int   `json:"checkpoint_delay,omitempty"`
+	// SkipMatching leaves instances that already run the launch template version alone, true when not set
+	SkipMatching *bool `json:"skip_matching,omitempty"`
+	// Triggers are other properties of the group that start a refresh when they change, e.g. tag
+	Triggers []string `json:"triggers,omitempty"`
+}
+
+func (r InstanceRefresh) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+func (r InstanceRefresh) Validate() error {
+	if percentage := r.MinHealthyPercentage; percentage != nil && (*percentage < 0 || *percentage > 100) {
+		return fmt.Errorf(`{"status": 400, "msg": "Incorrect param instance_refresh.min_healthy_percentage %d, expected a percentage."}`, *percentage)
+	}
+
+	if warmup := r.InstanceWarmup; warmup != nil && *warmup < 0 {
+		return fmt.Errorf(`{"status": 400, "msg": "Incorrect param instance_refresh.instance_warmup %d."}`, *warmup)
+	}
+
+	previous := 0
+	for _, percentage := range r.CheckpointPercentages {
+		if percentage <= previous || percentage > 100 {
+			return fmt.Errorf(`{"status": 400, "msg": "Checkpoint percentages %v of the instance refresh must be increasing percentages."}`, r.CheckpointPercentages)
+		}
+
+		previous = percentage
+	}
+
+	// AWS ends the refresh at the last checkpoint, anything below 100 leaves instances on the old launch template
+	if len(r.CheckpointPercentages) > 0 && previous != 100 {
+		return fmt.Errorf(`{"status": 400, "msg": "Checkpoint percentages %v of the instance refresh must end with 100."}`, r.CheckpointPercentages)
+	}
+
+	if r.CheckpointDelay < 0 || r.CheckpointDelay > 172800 {
+		return fmt.Errorf(`{"status": 400, "msg": "Checkpoint delay %d of the instance refresh must be between 0 and 172800 seconds."}`, r.CheckpointDelay)
+	}
+
+	return nil
+}
+
+// instanceRefreshArgs configures a rolling refresh, the group refreshes on its own when its launch template changes
+// so only the extra triggers are listed.
+func instanceRefreshArgs(refresh InstanceRefresh, healthCheckGracePeriod int) *autoscaling.GroupInstanceRefreshArgs {
+	minHealthyPercentage := 90
+	if refresh.MinHealthyPercentage != nil {
+		minHealthyPercentage = *refresh.MinHealthyPercentage
+	}
+
+	instanceWarmup := healthCheckGracePeriod
+	if refresh.InstanceWarmup != nil {
+		instanceWarmup = *refresh.InstanceWarmup
+	}
+
+	skipMatching := refresh.SkipMatching == nil || *refresh.SkipMatching
+
+	preferences := &autoscaling.GroupInstanceRefreshPreferencesArgs{
+		MinHealthyPercentage: pulumi.Int(minHealthyPercentage),
+		InstanceWarmup:       pulumi.String(strconv.Itoa(instanceWarmup)),
+		SkipMatching:         pulumi.Bool(skipMatching),
+	}
+
+	if len(refresh.CheckpointPercentages) > 0 {
+		checkpointDelay := refresh.CheckpointDelay
+		if checkpointDelay == 0 {
+			checkpointDelay = 3600
+		}
+
+		preferences.CheckpointPercentages = pulumi.ToIntArray(refresh.CheckpointPercentages)
+		preferences.CheckpointDelay = pulumi.String(strconv.Itoa(checkpointDelay))
+	}
+
+	args := &autoscaling.GroupInstanceRefreshArgs{
+		Strategy:    pulumi.String("Rolling"),
+		Preferences: preferences,
+	}
+
+	if len(refresh.Triggers) > 0 {
+		args.Triggers = pulumi.ToStringArray(refresh.Triggers)
+	}
+
+	return args
+}
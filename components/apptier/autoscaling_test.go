@@ -0,0 +1,110 @@
+package apptier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateInstanceRefresh(t *testing.T) {
+	tests := []struct {
+		name    string
+		refresh InstanceRefresh
+		wantErr string
+	}{
+		{
+			name:    "checkpoints",
+			refresh: InstanceRefresh{MinHealthyPercentage: intPtr(100), CheckpointPercentages: []int{20, 50, 100}, CheckpointDelay: 600},
+		},
+		{
+			name:    "min healthy percentage over 100",
+			refresh: InstanceRefresh{MinHealthyPercentage: intPtr(120)},
+			wantErr: "min_healthy_percentage",
+		},
+		{
+			name:    "checkpoints out of order",
+			refresh: InstanceRefresh{CheckpointPercentages: []int{50, 20}},
+			wantErr: "increasing percentages",
+		},
+		{
+			name:    "checkpoints stop before 100",
+			refresh: InstanceRefresh{CheckpointPercentages: []int{20, 50}, CheckpointDelay: 600},
+			wantErr: "must end with 100",
+		},
+		{
+			name:    "checkpoint delay over 2 days",
+			refresh: InstanceRefresh{CheckpointPercentages: []int{50, 100}, CheckpointDelay: 172801},
+			wantErr: "Checkpoint delay",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.refresh.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() = %v, want no error", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAutoScaling(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  AutoScaling
+		wantErr string
+	}{
+		{
+			name:   "defaults",
+			config: AutoScaling{},
+		},
+		{
+			name:   "all settings",
+			config: AutoScaling{MinSize: intPtr(2), DesiredCapacity: intPtr(2), MaxSize: 6, HealthCheckType: "EC2", TerminationPolicies: []string{"OldestLaunchTemplate", "Default"}, MaxInstanceLifetime: 604800},
+		},
+		{
+			name:    "desired capacity over max size",
+			config:  AutoScaling{DesiredCapacity: intPtr(4)},
+			wantErr: "desired_capacity 4 <= max_size 3",
+		},
+		{
+			name:    "min size over max size",
+			config:  AutoScaling{MinSize: intPtr(2), MaxSize: 1},
+			wantErr: "min_size 2",
+		},
+		{
+			name:    "unsupported health check type",
+			config:  AutoScaling{HealthCheckType: "VPC_LATTICE"},
+			wantErr: "health_check_type",
+		},
+		{
+			name:    "unsupported termination policy",
+			config:  AutoScaling{TerminationPolicies: []string{"Random"}},
+			wantErr: "termination policy Random",
+		},
+		{
+			name:    "max instance lifetime under a day",
+			config:  AutoScaling{MaxInstanceLifetime: 3600},
+			wantErr: "max_instance_lifetime",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			SetAutoScalingDefaults(&config)
+
+			err := config.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() = %v, want no error", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package apptier
+
+import (
+	"strconv"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/autoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/encryption"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+// fleet is a launch template and the autoscaling group launched from it, scaled by the configured policies. The app runs on
+// a single fleet, or on a blue and a green fleet when blue/green is enabled.
+type fleet struct {
+	// name is the component of the physical names of the fleet
+	name string
+	// logicalSuffix is empty for the single and the blue fleet, so that the blue fleet keeps the URNs of the single one
+	logicalSuffix   string
+	amiID           string
+	targetGroupArns pulumi.StringArrayInput
+	// targetGroupArnSuffix is the app target group of the fleet in CloudWatch metrics
+	targetGroupArnSuffix pulumi.StringInput
+}
+
+func (f fleet) logicalName(name string) string {
+	return name + f.logicalSuffix
+}
+
+// physicalName keeps the name the resources of the single fleet had before the naming scheme, the blue fleet took
+// them over and the green fleet always uses the naming scheme.
+func (f fleet) physicalName(namer *naming.Namer, service naming.Service, existingName string) string {
+	if f.logicalSuffix == "" {
+		return namer.Existing(service, f.name, existingName)
+	}
+
+	return namer.Name(service, f.name)
+}
+
+// newFleet expects the autoscaling config of args to have its defaults set.
+func newFleet(ctx *pulumi.Context, args *AppTierArgs, f fleet, keyName string, opts ...pulumi.ResourceOption) (*autoscaling.Group, *ec2.LaunchTemplate, error) {
+	instance := args.Instance
+
+	launchTemplate, err := ec2.NewLaunchTemplate(ctx, f.logicalName("example_launch_template"), &ec2.LaunchTemplateArgs{
+		Name:                  pulumi.String(f.physicalName(args.Namer, naming.LaunchTemplate, "asg_launch_config")),
+		InstanceType:          pulumi.String(instance.InstanceType),
+		KeyName:               pulumi.String(keyName),
+		ImageId:               pulumi.String(f.amiID),
+		IamInstanceProfile:    &ec2.LaunchTemplateIamInstanceProfileArgs{Name: args.InstanceProfileName},
+		UserData:              args.UserData,
+		DisableApiTermination: pulumi.Bool(instance.DisableApiTermination),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(instance.InstanceName),
+		},
+		NetworkInterfaces: ec2.LaunchTemplateNetworkInterfaceArray{
+			&ec2.LaunchTemplateNetworkInterfaceArgs{
+				AssociatePublicIpAddress: pulumi.String("true"),
+				SecurityGroups: pulumi.StringArray{
+					args.SecurityGroupID,
+				},
+			},
+		},
+		BlockDeviceMappings: ec2.LaunchTemplateBlockDeviceMappingArray{
+			&ec2.LaunchTemplateBlockDeviceMappingArgs{
+				DeviceName: pulumi.String(instance.DeviceType),
+				Ebs: &ec2.LaunchTemplateBlockDeviceMappingEbsArgs{
+					VolumeType:          pulumi.String(instance.VolumeType), // Use General Purpose SSD (GP2)
+					VolumeSize:          pulumi.Int(instance.VolumeSize),    // Set root volume size to 25 GB
+					DeleteOnTermination: pulumi.String("true"),              // Root volume is deleted when instance is terminated
+					Encrypted:           pulumi.String("true"),
+					KmsKeyId:            encryption.KMSKeyArn(args.EbsKmsKey),
+				},
+			},
+		},
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	launchTemplateVersion := launchTemplate.LatestVersion.ApplyT(func(num int) string {
+		return strconv.Itoa(num)
+	}).(pulumi.StringOutput)
+
+	config := args.AutoScaling
+	groupArgs := &autoscaling.GroupArgs{
+		Name:                   pulumi.String(f.physicalName(args.Namer, naming.AutoScalingGroup, "webapp-auto-scaling-group")),
+		VpcZoneIdentifiers:     args.SubnetIDs,
+		DefaultCooldown:        pulumi.IntPtr(*config.DefaultCooldown),
+		DesiredCapacity:        pulumi.IntPtr(*config.DesiredCapacity),
+		MaxSize:                pulumi.Int(config.MaxSize),
+		MinSize:                pulumi.Int(*config.MinSize),
+		HealthCheckGracePeriod: pulumi.Int(*config.HealthCheckGracePeriod),
+		HealthCheckType:        pulumi.String(config.HealthCheckType),
+		CapacityRebalance:      pulumi.Bool(config.CapacityRebalance),
+		Tags: autoscaling.GroupTagArray{
+			&autoscaling.GroupTagArgs{
+				Key:               pulumi.String("Name"),
+				Value:             pulumi.String(instance.InstanceName),
+				PropagateAtLaunch: pulumi.Bool(true),
+			},
+		},
+		TargetGroupArns: f.targetGroupArns,
+		LaunchTemplate: &autoscaling.GroupLaunchTemplateArgs{
+			Id:      launchTemplate.ID(),
+			Version: launchTemplateVersion,
+		},
+	}
+
+	if len(config.TerminationPolicies) > 0 {
+		groupArgs.TerminationPolicies = pulumi.ToStringArray(config.TerminationPolicies)
+	}
+
+	if config.MaxInstanceLifetime != 0 {
+		groupArgs.MaxInstanceLifetime = pulumi.Int(config.MaxInstanceLifetime)
+	}
+
+	// A new AMI or user data is a new launch template version, which rolls the instances
+	if refresh := args.AutoScaling.InstanceRefresh; refresh.IsEnabled() {
+		groupArgs.InstanceRefresh = instanceRefreshArgs(refresh, *config.HealthCheckGracePeriod)
+	}
+
+	autoscalingGroup, err := autoscaling.NewGroup(ctx, f.logicalName("example_auto_scaling_group"), groupArgs, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = newScalingPolicies(ctx, args, f, autoscalingGroup, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return autoscalingGroup, launchTemplate, nil
+}
@@ -0,0 +1,87 @@
+package apptier
+
+import (
+	"encoding/json"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type InstanceRoleArgs struct {
+	RoleName string
+}
+
+// InstanceRole is the IAM role and instance profile the app instances run with. It is created ahead of the
+// app tier because the KMS key policies and the messaging policy attachment need the role first.
+type InstanceRole struct {
+	pulumi.ResourceState
+
+	RoleID              pulumi.IDOutput
+	RoleArn             pulumi.StringOutput
+	InstanceProfileName pulumi.StringOutput
+}
+
+func NewInstanceRole(ctx *pulumi.Context, name string, args *InstanceRoleArgs, opts ...pulumi.ResourceOption) (*InstanceRole, error) {
+	instanceRole := &InstanceRole{}
+	err := ctx.RegisterComponentResource("webapp:apptier:InstanceRole", name, instanceRole, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources were created at the top level of the stack before they were grouped, keep their URNs
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(instanceRole), pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}})}
+
+	// Create IAM role for EC2 instance
+	ec2CloudWatchRoleStr, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect": "Allow",
+				"Action": []string{"sts:AssumeRole"},
+				"Principal": map[string]interface{}{
+					"Service": []string{"ec2.amazonaws.com"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := iam.NewRole(ctx, args.RoleName, &iam.RoleArgs{
+		AssumeRolePolicy: pulumi.String(ec2CloudWatchRoleStr),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach CloudWatchAgentServerPolicy to the new role
+	_, err = iam.NewRolePolicyAttachment(ctx, "ec2CloudWatchPolicy", &iam.RolePolicyAttachmentArgs{
+		Role:      role.ID(),
+		PolicyArn: pulumi.String("arn:aws:iam::aws:policy/CloudWatchAgentServerPolicy"),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceProfile, err := iam.NewInstanceProfile(ctx, "ec2CloudWatchProfile", &iam.InstanceProfileArgs{
+		Role: role.Name,
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceRole.RoleID = role.ID()
+	instanceRole.RoleArn = role.Arn
+	instanceRole.InstanceProfileName = instanceProfile.Name
+
+	err = ctx.RegisterResourceOutputs(instanceRole, pulumi.Map{
+		"roleArn":             instanceRole.RoleArn,
+		"instanceProfileName": instanceRole.InstanceProfileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return instanceRole, nil
+}
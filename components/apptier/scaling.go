@@ -0,0 +1,346 @@
+package apptier
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/autoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+const (
+	MetricCPU             = "cpu"
+	MetricALBRequestCount = "alb_request_count"
+	MetricCustom          = "custom"
+)
+
+type Metric struct {
+	// Type is cpu, alb_request_count for the requests per instance of the app target group, or custom
+	Type string `json:"type"`
+	// Namespace, Name, Statistic, Unit and Dimensions describe a custom metric, the statistic is Average when not set
+	Namespace  string            `json:"namespace,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Statistic  string            `json:"statistic,omitempty"`
+	Unit       string            `json:"unit,omitempty"`
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+}
+
+// TargetTrackingPolicy keeps the metric at the target value, AWS creates and manages the alarms of the policy.
+type TargetTrackingPolicy struct {
+	Name                    string  `json:"name"`
+	Metric                  Metric  `json:"metric"`
+	TargetValue             float64 `json:"target_value"`
+	DisableScaleIn          bool    `json:"disable_scale_in,omitempty"`
+	EstimatedInstanceWarmup int     `json:"estimated_instance_warmup,omitempty"`
+}
+
+type StepAdjustment struct {
+	// LowerBound and UpperBound are relative to the threshold of the alarm, a bound that is not set is infinity
+	LowerBound *float64 `json:"lower_bound,omitempty"`
+	UpperBound *float64 `json:"upper_bound,omitempty"`
+	Adjustment int      `json:"adjustment"`
+}
+
+// StepScalingPolicy changes the capacity by the step the metric falls in while its alarm is in alarm.
+type StepScalingPolicy struct {
+	Name   string `json:"name"`
+	Metric Metric `json:"metric"`
+	// ComparisonOperator and Threshold define when the alarm of the policy goes off
+	ComparisonOperator string  `json:"comparison_operator"`
+	Threshold          float64 `json:"threshold"`
+	// Period is 60 seconds and EvaluationPeriods is 2 when not set
+	Period            int `json:"period,omitempty"`
+	EvaluationPeriods int `json:"evaluation_periods,omitempty"`
+	// AdjustmentType is ChangeInCapacity, ExactCapacity or PercentChangeInCapacity, ChangeInCapacity when not set
+	AdjustmentType          string           `json:"adjustment_type,omitempty"`
+	Steps                   []StepAdjustment `json:"steps"`
+	EstimatedInstanceWarmup int              `json:"estimated_instance_warmup,omitempty"`
+}
+
+type Scaling struct {
+	TargetTracking []TargetTrackingPolicy `json:"target_tracking,omitempty"`
+	StepScaling    []StepScalingPolicy    `json:"step_scaling,omitempty"`
+}
+
+// DefaultScaling keeps the average CPU of the group at 50% when no scaling policies are configured.
+var DefaultScaling = Scaling{
+	TargetTracking: []TargetTrackingPolicy{{Name: "cpu", Metric: Metric{Type: MetricCPU}, TargetValue: 50}},
+}
+
+var (
+	comparisonOperators = []string{"GreaterThanOrEqualToThreshold", "GreaterThanThreshold", "LessThanThreshold", "LessThanOrEqualToThreshold"}
+	adjustmentTypes     = []string{"ChangeInCapacity", "ExactCapacity", "PercentChangeInCapacity"}
+)
+
+func (m Metric) Validate(policy string) error {
+	switch m.Type {
+	case MetricCPU, MetricALBRequestCount:
+	case MetricCustom:
+		if m.Namespace == "" || m.Name == "" {
+			return fmt.Errorf(`{"status": 400, "msg": "Custom metric of scaling policy %s needs a namespace and a name."}`, policy)
+		}
+	default:
+		return fmt.Errorf(`{"status": 400, "msg": "Unsupported metric %q of scaling policy %s, expected cpu, alb_request_count or custom."}`, m.Type, policy)
+	}
+
+	return nil
+}
+
+func (s Scaling) Validate() error {
+	names := map[string]bool{}
+	checkName := func(name string) error {
+		if name == "" || names[name] {
+			return fmt.Errorf(`{"status": 400, "msg": "Scaling policy names must be unique and not empty, got %q."}`, name)
+		}
+
+		names[name] = true
+		return nil
+	}
+
+	for _, policy := range s.TargetTracking {
+		if err := checkName(policy.Name); err != nil {
+			return err
+		}
+
+		if err := policy.Metric.Validate(policy.Name); err != nil {
+			return err
+		}
+
+		if policy.TargetValue <= 0 {
+			return fmt.Errorf(`{"status": 400, "msg": "Target value of scaling policy %s must be positive."}`, policy.Name)
+		}
+	}
+
+	for _, policy := range s.StepScaling {
+		if err := checkName(policy.Name); err != nil {
+			return err
+		}
+
+		if err := policy.Metric.Validate(policy.Name); err != nil {
+			return err
+		}
+
+		if !slices.Contains(comparisonOperators, policy.ComparisonOperator) {
+			return fmt.Errorf(`{"status": 400, "msg": "Unsupported comparison operator %q of scaling policy %s."}`, policy.ComparisonOperator, policy.Name)
+		}
+
+		if policy.AdjustmentType != "" && !slices.Contains(adjustmentTypes, policy.AdjustmentType) {
+			return fmt.Errorf(`{"status": 400, "msg": "Unsupported adjustment type %s of scaling policy %s."}`, policy.AdjustmentType, policy.Name)
+		}
+
+		if err := validateSteps(policy.Name, policy.Steps); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateSteps checks that the steps cover a single range without gaps or overlaps, as AWS requires.
+func validateSteps(policy string, steps []StepAdjustment) error {
+	if len(steps) == 0 {
+		return fmt.Errorf(`{"status": 400, "msg": "Step scaling policy %s needs steps."}`, policy)
+	}
+
+	sorted := slices.Clone(steps)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].LowerBound == nil || (sorted[j].LowerBound != nil && *sorted[i].LowerBound < *sorted[j].LowerBound)
+	})
+
+	for i, step := range sorted {
+		if step.LowerBound != nil && step.UpperBound != nil && *step.LowerBound >= *step.UpperBound {
+			return fmt.Errorf(`{"status": 400, "msg": "Step of scaling policy %s has a lower bound %v that isn't under its upper bound %v."}`, policy, *step.LowerBound, *step.UpperBound)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		previous := sorted[i-1]
+		if previous.UpperBound == nil || step.LowerBound == nil || *previous.UpperBound != *step.LowerBound {
+			return fmt.Errorf(`{"status": 400, "msg": "Steps of scaling policy %s must follow each other without gaps or overlaps."}`, policy)
+		}
+	}
+
+	return nil
+}
+
+func optionalBound(bound *float64) pulumi.StringPtrInput {
+	if bound == nil {
+		return nil
+	}
+
+	return pulumi.String(strconv.FormatFloat(*bound, 'f', -1, 64))
+}
+
+// sortedDimensions keeps the order of the dimensions stable between runs, the config map has none.
+func sortedDimensions(dimensions map[string]string) []string {
+	names := make([]string, 0, len(dimensions))
+	for name := range dimensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// newScalingPolicies creates the scaling policies of the group of a fleet and the alarms of its step scaling
+// policies.
+func newScalingPolicies(ctx *pulumi.Context, args *AppTierArgs, f fleet, group *autoscaling.Group, opts ...pulumi.ResourceOption) error {
+	scaling := args.AutoScaling.Scaling
+	if len(scaling.TargetTracking) == 0 && len(scaling.StepScaling) == 0 {
+		scaling = DefaultScaling
+	}
+
+	// ALB request count metrics are identified by the load balancer and the app target group of the fleet
+	resourceLabel := pulumi.Sprintf("%s/%s", args.LoadBalancerArnSuffix, f.targetGroupArnSuffix)
+
+	for _, policy := range scaling.TargetTracking {
+		configuration := &autoscaling.PolicyTargetTrackingConfigurationArgs{
+			TargetValue:    pulumi.Float64(policy.TargetValue),
+			DisableScaleIn: pulumi.Bool(policy.DisableScaleIn),
+		}
+
+		switch metric := policy.Metric; metric.Type {
+		case MetricCPU:
+			configuration.PredefinedMetricSpecification = &autoscaling.PolicyTargetTrackingConfigurationPredefinedMetricSpecificationArgs{
+				PredefinedMetricType: pulumi.String("ASGAverageCPUUtilization"),
+			}
+		case MetricALBRequestCount:
+			configuration.PredefinedMetricSpecification = &autoscaling.PolicyTargetTrackingConfigurationPredefinedMetricSpecificationArgs{
+				PredefinedMetricType: pulumi.String("ALBRequestCountPerTarget"),
+				ResourceLabel:        resourceLabel,
+			}
+		case MetricCustom:
+			var dimensions autoscaling.PolicyTargetTrackingConfigurationCustomizedMetricSpecificationMetricDimensionArray
+			for _, name := range sortedDimensions(metric.Dimensions) {
+				dimensions = append(dimensions, &autoscaling.PolicyTargetTrackingConfigurationCustomizedMetricSpecificationMetricDimensionArgs{
+					Name:  pulumi.String(name),
+					Value: pulumi.String(metric.Dimensions[name]),
+				})
+			}
+
+			configuration.CustomizedMetricSpecification = &autoscaling.PolicyTargetTrackingConfigurationCustomizedMetricSpecificationArgs{
+				Namespace:        pulumi.String(metric.Namespace),
+				MetricName:       pulumi.String(metric.Name),
+				Statistic:        pulumi.String(metricStatistic(metric, "Average")),
+				Unit:             optionalString(metric.Unit),
+				MetricDimensions: dimensions,
+			}
+		}
+
+		policyArgs := &autoscaling.PolicyArgs{
+			Name:                        pulumi.String(policy.Name),
+			AutoscalingGroupName:        group.Name,
+			PolicyType:                  pulumi.String("TargetTrackingScaling"),
+			TargetTrackingConfiguration: configuration,
+		}
+		if policy.EstimatedInstanceWarmup != 0 {
+			policyArgs.EstimatedInstanceWarmup = pulumi.Int(policy.EstimatedInstanceWarmup)
+		}
+
+		_, err := autoscaling.NewPolicy(ctx, f.logicalName(fmt.Sprintf("target-tracking-%s", policy.Name)), policyArgs, opts...)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, policy := range scaling.StepScaling {
+		adjustmentType := policy.AdjustmentType
+		if adjustmentType == "" {
+			adjustmentType = "ChangeInCapacity"
+		}
+
+		var steps autoscaling.PolicyStepAdjustmentArray
+		for _, step := range policy.Steps {
+			steps = append(steps, &autoscaling.PolicyStepAdjustmentArgs{
+				MetricIntervalLowerBound: optionalBound(step.LowerBound),
+				MetricIntervalUpperBound: optionalBound(step.UpperBound),
+				ScalingAdjustment:        pulumi.Int(step.Adjustment),
+			})
+		}
+
+		policyArgs := &autoscaling.PolicyArgs{
+			Name:                  pulumi.String(policy.Name),
+			AutoscalingGroupName:  group.Name,
+			PolicyType:            pulumi.String("StepScaling"),
+			AdjustmentType:        pulumi.String(adjustmentType),
+			MetricAggregationType: pulumi.String("Average"),
+			StepAdjustments:       steps,
+		}
+		if policy.EstimatedInstanceWarmup != 0 {
+			policyArgs.EstimatedInstanceWarmup = pulumi.Int(policy.EstimatedInstanceWarmup)
+		}
+
+		stepPolicy, err := autoscaling.NewPolicy(ctx, f.logicalName(fmt.Sprintf("step-scaling-%s", policy.Name)), policyArgs, opts...)
+		if err != nil {
+			return err
+		}
+
+		period := policy.Period
+		if period == 0 {
+			period = 60
+		}
+
+		evaluationPeriods := policy.EvaluationPeriods
+		if evaluationPeriods == 0 {
+			evaluationPeriods = 2
+		}
+
+		alarmArgs := &cloudwatch.MetricAlarmArgs{
+			Name:               pulumi.String(args.Namer.Name(naming.MetricAlarm, fmt.Sprintf("%s-%s", f.name, policy.Name))),
+			ComparisonOperator: pulumi.String(policy.ComparisonOperator),
+			Threshold:          pulumi.Float64(policy.Threshold),
+			EvaluationPeriods:  pulumi.Int(evaluationPeriods),
+			Period:             pulumi.Int(period),
+			AlarmDescription:   pulumi.String(fmt.Sprintf("Scales the app with step scaling policy %s", policy.Name)),
+			AlarmActions:       pulumi.Array{stepPolicy.Arn},
+		}
+
+		switch metric := policy.Metric; metric.Type {
+		case MetricCPU:
+			alarmArgs.Namespace = pulumi.String("AWS/EC2")
+			alarmArgs.MetricName = pulumi.String("CPUUtilization")
+			alarmArgs.Statistic = pulumi.String("Average")
+			alarmArgs.Dimensions = pulumi.StringMap{"AutoScalingGroupName": group.Name}
+		case MetricALBRequestCount:
+			alarmArgs.Namespace = pulumi.String("AWS/ApplicationELB")
+			alarmArgs.MetricName = pulumi.String("RequestCountPerTarget")
+			alarmArgs.Statistic = pulumi.String("Sum")
+			alarmArgs.Dimensions = pulumi.StringMap{"TargetGroup": f.targetGroupArnSuffix}
+		case MetricCustom:
+			alarmArgs.Namespace = pulumi.String(metric.Namespace)
+			alarmArgs.MetricName = pulumi.String(metric.Name)
+			alarmArgs.Statistic = pulumi.String(metricStatistic(metric, "Average"))
+			alarmArgs.Unit = optionalString(metric.Unit)
+			alarmArgs.Dimensions = pulumi.ToStringMap(metric.Dimensions)
+		}
+
+		_, err = cloudwatch.NewMetricAlarm(ctx, f.logicalName(fmt.Sprintf("step-scaling-%s-alarm", policy.Name)), alarmArgs, opts...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func metricStatistic(metric Metric, defaultStatistic string) string {
+	if metric.Statistic == "" {
+		return defaultStatistic
+	}
+
+	return metric.Statistic
+}
+
+func optionalString(value string) pulumi.StringPtrInput {
+	if value == "" {
+		return nil
+	}
+
+	return pulumi.String(value)
+}
@@ -0,0 +1,94 @@
+package apptier
+
+import (
+	"strings"
+	"testing"
+)
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func TestValidateScaling(t *testing.T) {
+	cpuSteps := []StepAdjustment{
+		{LowerBound: floatPtr(0), UpperBound: floatPtr(20), Adjustment: 1},
+		{LowerBound: floatPtr(20), Adjustment: 2},
+	}
+
+	tests := []struct {
+		name    string
+		scaling Scaling
+		wantErr string
+	}{
+		{
+			name:    "default",
+			scaling: DefaultScaling,
+		},
+		{
+			name: "target tracking and step scaling",
+			scaling: Scaling{
+				TargetTracking: []TargetTrackingPolicy{
+					{Name: "requests", Metric: Metric{Type: MetricALBRequestCount}, TargetValue: 1000},
+					{Name: "queue", Metric: Metric{Type: MetricCustom, Namespace: "webapp", Name: "QueueDepth", Dimensions: map[string]string{"Queue": "jobs"}}, TargetValue: 100},
+				},
+				StepScaling: []StepScalingPolicy{
+					{Name: "cpu-high", Metric: Metric{Type: MetricCPU}, ComparisonOperator: "GreaterThanOrEqualToThreshold", Threshold: 70, Steps: cpuSteps},
+					{Name: "cpu-low", Metric: Metric{Type: MetricCPU}, ComparisonOperator: "LessThanThreshold", Threshold: 20, Steps: []StepAdjustment{{UpperBound: floatPtr(0), Adjustment: -1}}},
+				},
+			},
+		},
+		{
+			name:    "duplicate names",
+			scaling: Scaling{TargetTracking: []TargetTrackingPolicy{{Name: "cpu", Metric: Metric{Type: MetricCPU}, TargetValue: 50}}, StepScaling: []StepScalingPolicy{{Name: "cpu", Metric: Metric{Type: MetricCPU}, ComparisonOperator: "GreaterThanThreshold", Steps: cpuSteps}}},
+			wantErr: "unique",
+		},
+		{
+			name:    "unsupported metric",
+			scaling: Scaling{TargetTracking: []TargetTrackingPolicy{{Name: "memory", Metric: Metric{Type: "memory"}, TargetValue: 50}}},
+			wantErr: "Unsupported metric",
+		},
+		{
+			name:    "target value not positive",
+			scaling: Scaling{TargetTracking: []TargetTrackingPolicy{{Name: "cpu", Metric: Metric{Type: MetricCPU}}}},
+			wantErr: "Target value",
+		},
+		{
+			name:    "unsupported comparison operator",
+			scaling: Scaling{StepScaling: []StepScalingPolicy{{Name: "cpu", Metric: Metric{Type: MetricCPU}, ComparisonOperator: ">", Steps: cpuSteps}}},
+			wantErr: "comparison operator",
+		},
+		{
+			name:    "no steps",
+			scaling: Scaling{StepScaling: []StepScalingPolicy{{Name: "cpu", Metric: Metric{Type: MetricCPU}, ComparisonOperator: "GreaterThanThreshold"}}},
+			wantErr: "needs steps",
+		},
+		{
+			name: "gap between steps",
+			scaling: Scaling{StepScaling: []StepScalingPolicy{{Name: "cpu", Metric: Metric{Type: MetricCPU}, ComparisonOperator: "GreaterThanThreshold", Steps: []StepAdjustment{
+				{LowerBound: floatPtr(0), UpperBound: floatPtr(10), Adjustment: 1},
+				{LowerBound: floatPtr(20), Adjustment: 2},
+			}}}},
+			wantErr: "without gaps or overlaps",
+		},
+		{
+			name: "step bounds reversed",
+			scaling: Scaling{StepScaling: []StepScalingPolicy{{Name: "cpu", Metric: Metric{Type: MetricCPU}, ComparisonOperator: "GreaterThanThreshold", Steps: []StepAdjustment{
+				{LowerBound: floatPtr(10), UpperBound: floatPtr(0), Adjustment: 1},
+			}}}},
+			wantErr: "lower bound 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.scaling.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() = %v, want no error", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
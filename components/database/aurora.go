@@ -0,0 +1,178 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/rds"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/encryption"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+// NewAuroraCluster creates an Aurora PostgreSQL cluster with its cluster parameter group, a writer and the
+// configured number of reader instances. Serverless clusters use Serverless v2 instances scaled between min and max ACUs.
+func NewAuroraCluster(ctx *pulumi.Context, metadata *RDSInstance, namer *naming.Namer, subnetGroupName pulumi.StringInput, securityGroupID pulumi.IDOutput, kmsKey *kms.Key, opts ...pulumi.ResourceOption) (*RDSDatabase, error) {
+	aurora := metadata.Aurora
+
+	if len(metadata.ReadReplicas) > 0 {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "Read replicas are not supported in %s mode, use aurora.reader_count instead."}`, RDSModeAurora)
+	}
+
+	if aurora.ReaderCount < 0 {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "Incorrect param aurora.reader_count %d."}`, aurora.ReaderCount)
+	}
+
+	clusterIdentifier := aurora.ClusterIdentifier
+	if clusterIdentifier == "" {
+		clusterIdentifier = fmt.Sprintf("%s-cluster", metadata.Identifier)
+	}
+
+	instanceClass := aurora.InstanceClass
+	if instanceClass == "" {
+		instanceClass = metadata.InstanceClass
+	}
+
+	var serverlessScaling *rds.ClusterServerlessv2ScalingConfigurationArgs
+	if aurora.Serverless {
+		if aurora.MinCapacity < 0.5 || aurora.MaxCapacity > 128 || aurora.MinCapacity > aurora.MaxCapacity {
+			return nil, fmt.Errorf(`{"status": 400, "msg": "Serverless v2 capacity must satisfy 0.5 <= min_capacity (%v) <= max_capacity (%v) <= 128."}`, aurora.MinCapacity, aurora.MaxCapacity)
+		}
+
+		instanceClass = "db.serverless"
+		serverlessScaling = &rds.ClusterServerlessv2ScalingConfigurationArgs{
+			MinCapacity: pulumi.Float64(aurora.MinCapacity),
+			MaxCapacity: pulumi.Float64(aurora.MaxCapacity),
+		}
+	}
+
+	// Derive the cluster parameter group family from the engine version unless explicitly configured
+	clusterParameterGroupFamily := aurora.ClusterParameterGroupFamily
+	if clusterParameterGroupFamily == "" {
+		family, err := RDSParameterGroupFamily(AuroraPostgresEngine, metadata.EngineVersion)
+		if err != nil {
+			return nil, fmt.Errorf(`{"status": 400, "msg": "%v"}`, err)
+		}
+
+		clusterParameterGroupFamily = family
+	}
+
+	clusterParameters, err := RDSClusterParameterGroupParameters(*metadata)
+	if err != nil {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "%v"}`, err)
+	}
+
+	clusterParameterGroup, err := rds.NewClusterParameterGroup(ctx, "webapp-cluster-parameter-group", &rds.ClusterParameterGroupArgs{
+		Description: pulumi.String("Custom cluster parameter group for webapp aurora cluster"),
+		Family:      pulumi.String(clusterParameterGroupFamily),
+		Name:        pulumi.String(namer.Existing(naming.RDSParameterGrp, fmt.Sprintf("aurora-%s", clusterParameterGroupFamily), fmt.Sprintf("webapp-aurora-%s", clusterParameterGroupFamily))),
+		Parameters:  clusterParameters,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill in production safe backup, maintenance and deletion protection settings
+	if err = SetRDSBackupDefaults(metadata); err != nil {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "%v"}`, err)
+	}
+
+	if *metadata.BackupRetentionPeriod == 0 {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "Backups can't be disabled in %s mode, backup_retention_period must be between 1 and 35."}`, RDSModeAurora)
+	}
+
+	if err = SetRDSMonitoringDefaults(metadata, AuroraPostgresEngine); err != nil {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "%v"}`, err)
+	}
+
+	monitoringRoleArn, err := rdsMonitoringRoleArn(ctx, metadata, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	finalSnapshotIdentifier := rdsFinalSnapshot(ctx, metadata, clusterIdentifier)
+
+	cluster, err := rds.NewCluster(ctx, clusterIdentifier, &rds.ClusterArgs{
+		ClusterIdentifier:                pulumi.String(namer.Existing(naming.RDSCluster, clusterIdentifier, clusterIdentifier)),
+		Engine:                           pulumi.String(AuroraPostgresEngine),
+		EngineMode:                       pulumi.String("provisioned"),
+		EngineVersion:                    pulumi.String(metadata.EngineVersion),
+		DatabaseName:                     pulumi.String(metadata.DbName),
+		MasterUsername:                   pulumi.String(metadata.Username),
+		MasterPassword:                   pulumi.String(metadata.Password),
+		Port:                             pulumi.Int(metadata.AllowsPort),
+		DbSubnetGroupName:                subnetGroupName,
+		DbClusterParameterGroupName:      clusterParameterGroup.Name,
+		VpcSecurityGroupIds:              pulumi.StringArray{securityGroupID},
+		StorageEncrypted:                 pulumi.Bool(metadata.StorageEncrypted || kmsKey != nil),
+		KmsKeyId:                         encryption.KMSKeyArn(kmsKey),
+		ApplyImmediately:                 pulumi.Bool(true),
+		BackupRetentionPeriod:            pulumi.Int(*metadata.BackupRetentionPeriod),
+		PreferredBackupWindow:            pulumi.String(metadata.BackupWindow),
+		PreferredMaintenanceWindow:       pulumi.String(metadata.MaintenanceWindow),
+		DeletionProtection:               pulumi.Bool(*metadata.DeletionProtection),
+		CopyTagsToSnapshot:               pulumi.Bool(*metadata.CopyTagsToSnapshot),
+		SkipFinalSnapshot:                pulumi.Bool(metadata.SkipFinalSnapShot),
+		FinalSnapshotIdentifier:          finalSnapshotIdentifier,
+		Serverlessv2ScalingConfiguration: serverlessScaling,
+		EnabledCloudwatchLogsExports:     pulumi.ToStringArray(metadata.CloudwatchLogsExports),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(clusterIdentifier),
+		},
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first instance created in the cluster becomes the writer, readers are created after it
+	var writer *rds.ClusterInstance
+	for i := 0; i <= aurora.ReaderCount; i++ {
+		instanceIdentifier := fmt.Sprintf("%s-writer", clusterIdentifier)
+		instanceOpts := append([]pulumi.ResourceOption{}, opts...)
+		if i > 0 {
+			instanceIdentifier = fmt.Sprintf("%s-reader-%d", clusterIdentifier, i)
+			instanceOpts = append(instanceOpts, pulumi.DependsOn([]pulumi.Resource{writer}))
+		}
+
+		clusterInstance, err := rds.NewClusterInstance(ctx, instanceIdentifier, &rds.ClusterInstanceArgs{
+			Identifier:                 pulumi.String(namer.Existing(naming.RDSInstance, instanceIdentifier, instanceIdentifier)),
+			ClusterIdentifier:          cluster.ID(),
+			InstanceClass:              pulumi.String(instanceClass),
+			Engine:                     cluster.Engine,
+			EngineVersion:              cluster.EngineVersion,
+			DbSubnetGroupName:          subnetGroupName,
+			PubliclyAccessible:         pulumi.Bool(false),
+			ApplyImmediately:           pulumi.Bool(true),
+			PromotionTier:              pulumi.Int(i),
+			PreferredMaintenanceWindow: pulumi.String(metadata.MaintenanceWindow),
+
+			PerformanceInsightsEnabled:         pulumi.Bool(metadata.PerformanceInsightsEnabled),
+			PerformanceInsightsRetentionPeriod: rdsPerformanceInsightsRetention(metadata),
+			PerformanceInsightsKmsKeyId:        rdsPerformanceInsightsKmsKeyArn(metadata, kmsKey),
+			MonitoringInterval:                 pulumi.Int(metadata.MonitoringInterval),
+			MonitoringRoleArn:                  monitoringRoleArn,
+			Tags: pulumi.StringMap{
+				"Name": pulumi.String(instanceIdentifier),
+			},
+		}, instanceOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			writer = clusterInstance
+		}
+	}
+
+	// Without readers the reader endpoint resolves to the writer, only hand it to the app when readers exist
+	readerHosts := pulumi.StringArray{}
+	if aurora.ReaderCount > 0 {
+		readerHosts = append(readerHosts, cluster.ReaderEndpoint)
+	}
+
+	return &RDSDatabase{
+		Cluster:     cluster,
+		WriterHost:  cluster.Endpoint,
+		ReaderHosts: readerHosts.ToStringArrayOutput(),
+	}, nil
+}
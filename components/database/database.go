@@ -0,0 +1,195 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/rds"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sns"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+type RDSInstance struct {
+	SubnetGrp          string `json:"private_subnet_group,omitempty"`
+	SecurityGroupName  string `json:"security_group_name,omitempty"`
+	AllowsPort         int    `json:"allows_port,omitempty"`
+	Protocol           string `json:"protocol,omitempty"`
+	InstanceName       string `json:"instance_name,omitempty"`
+	Engine             string `json:"engine,omitempty"`
+	EngineVersion      string `json:"engine_version,omitempty"`
+	InstanceClass      string `json:"instance_class,omitempty"`
+	AllowedStorage     int    `json:"allowed_storage,omitempty"`
+	Identifier         string `json:"identifier,omitempty"`
+	Username           string `json:"username,omitempty"`
+	Password           string `json:"password,omitempty"`
+	DbName             string `json:"db_name,omitempty"`
+	DbDriver           string `json:"db_driver,omitempty"`
+	PubliclyAccessible bool   `json:"publicly_accessible,omitempty"`
+	MultiAz            bool   `json:"multi_az,omitempty"`
+	SkipFinalSnapShot  bool   `json:"skip_final_snapshot,omitempty"`
+	StorageEncrypted   bool   `json:"storage_encrypted,omitempty"`
+
+	ParameterGroupFamily string                  `json:"parameter_group_family,omitempty"`
+	ParameterGroupName   string                  `json:"parameter_group_name,omitempty"`
+	Parameters           map[string]RDSParameter `json:"parameters,omitempty"`
+	ForceSSL             *bool                   `json:"force_ssl,omitempty"`
+
+	BackupRetentionPeriod   *int   `json:"backup_retention_period,omitempty"`
+	BackupWindow            string `json:"backup_window,omitempty"`
+	MaintenanceWindow       string `json:"maintenance_window,omitempty"`
+	DeletionProtection      *bool  `json:"deletion_protection,omitempty"`
+	FinalSnapshotIdentifier string `json:"final_snapshot_identifier,omitempty"`
+	CopyTagsToSnapshot      *bool  `json:"copy_tags_to_snapshot,omitempty"`
+
+	ReadReplicas []RDSReadReplica `json:"read_replicas,omitempty"`
+
+	Mode   string        `json:"mode,omitempty"`
+	Aurora AuroraCluster `json:"aurora,omitempty"`
+	Proxy  RDSProxy      `json:"proxy,omitempty"`
+
+	PerformanceInsightsEnabled   bool      `json:"performance_insights_enabled,omitempty"`
+	PerformanceInsightsRetention int       `json:"performance_insights_retention_period,omitempty"`
+	MonitoringInterval           int       `json:"monitoring_interval,omitempty"`
+	CloudwatchLogsExports        []string  `json:"cloudwatch_logs_exports,omitempty"`
+	Alarms                       RDSAlarms `json:"alarms,omitempty"`
+}
+
+type RDSReadReplica struct {
+	Identifier       string `json:"identifier,omitempty"`
+	InstanceClass    string `json:"instance_class,omitempty"`
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	Region           string `json:"region,omitempty"`
+	SubnetGrp        string `json:"subnet_group,omitempty"`
+	KmsKeyID         string `json:"kms_key_id,omitempty"`
+}
+
+type AuroraCluster struct {
+	ClusterIdentifier           string                  `json:"cluster_identifier,omitempty"`
+	InstanceClass               string                  `json:"instance_class,omitempty"`
+	ReaderCount                 int                     `json:"reader_count,omitempty"`
+	Serverless                  bool                    `json:"serverless,omitempty"`
+	MinCapacity                 float64                 `json:"min_capacity,omitempty"`
+	MaxCapacity                 float64                 `json:"max_capacity,omitempty"`
+	ClusterParameterGroupFamily string                  `json:"cluster_parameter_group_family,omitempty"`
+	ClusterParameters           map[string]RDSParameter `json:"cluster_parameters,omitempty"`
+}
+
+type RDSProxy struct {
+	Enabled               bool   `json:"enabled,omitempty"`
+	Name                  string `json:"name,omitempty"`
+	SecurityGroupName     string `json:"security_group_name,omitempty"`
+	RequireTLS            *bool  `json:"require_tls,omitempty"`
+	IdleClientTimeout     int    `json:"idle_client_timeout,omitempty"`
+	MaxConnectionsPercent int    `json:"max_connections_percent,omitempty"`
+	DebugLogging          bool   `json:"debug_logging,omitempty"`
+}
+
+type RDSAlarms struct {
+	Enabled                 *bool    `json:"enabled,omitempty"`
+	AlertTopic              string   `json:"alert_topic,omitempty"`
+	AlertEmails             []string `json:"alert_emails,omitempty"`
+	CPUThreshold            float64  `json:"cpu_threshold,omitempty"`
+	FreeStorageThresholdGiB float64  `json:"free_storage_threshold_gib,omitempty"`
+	ConnectionsThreshold    float64  `json:"connections_threshold,omitempty"`
+	ReplicaLagThreshold     float64  `json:"replica_lag_threshold,omitempty"`
+	EvaluationPeriods       int      `json:"evaluation_periods,omitempty"`
+	Period                  int      `json:"period,omitempty"`
+}
+
+// ComponentType is the type token of the database component.
+const ComponentType = "webapp:database:Database"
+
+type DatabaseArgs struct {
+	Metadata                RDSInstance
+	Region                  string
+	SubnetIDs               pulumi.StringArrayInput
+	DatabaseSecurityGroupID pulumi.IDOutput
+	// ProxySecurityGroupID is the security group of the RDS proxy, created with the other security groups so that
+	// the database security group accepts the proxy in its inline rules
+	ProxySecurityGroupID pulumi.IDOutput
+	KmsKey               *kms.Key
+	AlertKmsKey          *kms.Key
+	Namer                *naming.Namer
+}
+
+// Database is the database tier of the app, a single RDS instance with optional read replicas or an Aurora
+// cluster, optionally fronted by an RDS Proxy, together with its alarms.
+type Database struct {
+	pulumi.ResourceState
+
+	WriterHost  pulumi.StringOutput
+	ReaderHosts pulumi.StringArrayOutput
+	AlertTopic  *sns.Topic
+}
+
+func NewDatabase(ctx *pulumi.Context, name string, args *DatabaseArgs, opts ...pulumi.ResourceOption) (*Database, error) {
+	component := &Database{}
+	err := ctx.RegisterComponentResource(ComponentType, name, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources were created at the top level of the stack before they were grouped, keep their URNs
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(component), pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}})}
+
+	metadata := args.Metadata
+
+	// create a Subnet Group for all private subnets under a VPC.
+	subnetGroup, err := rds.NewSubnetGroup(ctx, metadata.SubnetGrp, &rds.SubnetGroupArgs{
+		Name:      args.Namer.AutoNamed(naming.RDSSubnetGroup, metadata.SubnetGrp),
+		SubnetIds: args.SubnetIDs,
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String("database-private-subnet-grp"),
+		},
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the database tier as a single RDS instance with optional read replicas or as an Aurora cluster
+	var database *RDSDatabase
+	switch metadata.Mode {
+	case "", RDSModeInstance:
+		database, err = NewRDSInstance(ctx, &metadata, args.Namer, args.Region, subnetGroup.Name, args.DatabaseSecurityGroupID, args.KmsKey, childOpts...)
+	case RDSModeAurora:
+		database, err = NewAuroraCluster(ctx, &metadata, args.Namer, subnetGroup.Name, args.DatabaseSecurityGroupID, args.KmsKey, childOpts...)
+	default:
+		err = fmt.Errorf(`{"status": 400, "msg": "Unsupported database mode %s."}`, metadata.Mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the default database alarms wired to the alert topic
+	component.AlertTopic, err = NewRDSAlarms(ctx, &metadata, args.Namer, args.Region, database, args.AlertKmsKey, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Route the app's database connections through an RDS Proxy so scale-outs reuse pooled connections
+	if metadata.Proxy.Enabled {
+		err = NewRDSProxy(ctx, &metadata, args.Namer, database, args.SubnetIDs, args.ProxySecurityGroupID, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	component.WriterHost = database.WriterHost
+	component.ReaderHosts = database.ReaderHosts
+
+	outputs := pulumi.Map{
+		"writerHost":  component.WriterHost,
+		"readerHosts": component.ReaderHosts,
+	}
+	if component.AlertTopic != nil {
+		outputs["alertTopicArn"] = component.AlertTopic.Arn
+	}
+
+	err = ctx.RegisterResourceOutputs(component, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return component, nil
+}
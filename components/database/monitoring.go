@@ -0,0 +1,241 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sns"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/encryption"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+// rdsLogExports lists the CloudWatch log types each engine can export.
+var rdsLogExports = map[string][]string{
+	"postgres":           {"postgresql", "upgrade"},
+	AuroraPostgresEngine: {"postgresql"},
+	"mysql":              {"audit", "error", "general", "slowquery"},
+	"mariadb":            {"audit", "error", "general", "slowquery"},
+}
+
+// SetRDSMonitoringDefaults validates the Performance Insights, enhanced monitoring and log export settings
+// of the given engine and fills in the defaults of the ones that are enabled.
+func SetRDSMonitoringDefaults(metadata *RDSInstance, engine string) error {
+	if metadata.PerformanceInsightsEnabled {
+		if metadata.PerformanceInsightsRetention == 0 {
+			metadata.PerformanceInsightsRetention = 7
+		}
+
+		// Retention is either the free tier 7 days, 731 days or a multiple of 31 days in between
+		retention := metadata.PerformanceInsightsRetention
+		if retention != 7 && retention != 731 && (retention < 31 || retention%31 != 0 || retention > 713) {
+			return fmt.Errorf("invalid performance insights retention period %d, expected 7, 731 or a multiple of 31", retention)
+		}
+	}
+
+	switch metadata.MonitoringInterval {
+	case 0, 1, 5, 10, 15, 30, 60:
+	default:
+		return fmt.Errorf("invalid monitoring interval %d, expected one of 0, 1, 5, 10, 15, 30 or 60 seconds", metadata.MonitoringInterval)
+	}
+
+	for _, logExport := range metadata.CloudwatchLogsExports {
+		supported := false
+		for _, engineLogExport := range rdsLogExports[engine] {
+			supported = supported || logExport == engineLogExport
+		}
+
+		if !supported {
+			return fmt.Errorf("log export %q is not supported by engine %s", logExport, engine)
+		}
+	}
+
+	return nil
+}
+
+// rdsMonitoringRoleArn creates the IAM role that enhanced monitoring uses to publish OS metrics to CloudWatch
+// and returns its ARN, nothing is created when enhanced monitoring is disabled.
+func rdsMonitoringRoleArn(ctx *pulumi.Context, metadata *RDSInstance, opts ...pulumi.ResourceOption) (pulumi.StringPtrInput, error) {
+	if metadata.MonitoringInterval == 0 {
+		return nil, nil
+	}
+
+	monitoringAssumeRolePolicy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect": "Allow",
+				"Action": []string{"sts:AssumeRole"},
+				"Principal": map[string]interface{}{
+					"Service": []string{"monitoring.rds.amazonaws.com"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	monitoringRole, err := iam.NewRole(ctx, "rdsMonitoringRole", &iam.RoleArgs{
+		AssumeRolePolicy: pulumi.String(monitoringAssumeRolePolicy),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String("rds-enhanced-monitoring-role"),
+		},
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = iam.NewRolePolicyAttachment(ctx, "rdsMonitoringPolicy", &iam.RolePolicyAttachmentArgs{
+		Role:      monitoringRole.Name,
+		PolicyArn: pulumi.String("arn:aws:iam::aws:policy/service-role/AmazonRDSEnhancedMonitoringRole"),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return monitoringRole.Arn, nil
+}
+
+// NewRDSAlarms creates the alert topic and the default database alarms on CPU, free storage, connections and
+// replica lag. Thresholds that are not configured use sensible defaults.
+func NewRDSAlarms(ctx *pulumi.Context, metadata *RDSInstance, namer *naming.Namer, region string, database *RDSDatabase, kmsKey *kms.Key, opts ...pulumi.ResourceOption) (*sns.Topic, error) {
+	alarms := metadata.Alarms
+	if alarms.Enabled != nil && !*alarms.Enabled {
+		return nil, nil
+	}
+
+	if alarms.CPUThreshold == 0 {
+		alarms.CPUThreshold = 80
+	}
+
+	if alarms.FreeStorageThresholdGiB == 0 {
+		alarms.FreeStorageThresholdGiB = 2
+	}
+
+	if alarms.ConnectionsThreshold == 0 {
+		alarms.ConnectionsThreshold = 100
+	}
+
+	if alarms.ReplicaLagThreshold == 0 {
+		alarms.ReplicaLagThreshold = 60
+	}
+
+	if alarms.EvaluationPeriods == 0 {
+		alarms.EvaluationPeriods = 3
+	}
+
+	if alarms.Period == 0 {
+		alarms.Period = 300
+	}
+
+	// The default topic and the alarms are named per stack, alarms are on by default and fixed names would collide
+	// between the stacks of an account
+	alertTopicName := namer.Name(naming.SNSTopic, "rds-alerts")
+	if alarms.AlertTopic != "" {
+		alertTopicName = namer.Existing(naming.SNSTopic, alarms.AlertTopic, alarms.AlertTopic)
+	}
+
+	alertTopic, err := sns.NewTopic(ctx, "rdsAlertTopic", &sns.TopicArgs{
+		Name:           pulumi.String(alertTopicName),
+		DisplayName:    pulumi.String("rds-alerts"),
+		KmsMasterKeyId: encryption.KMSKeyArn(kmsKey),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, email := range alarms.AlertEmails {
+		_, err = sns.NewTopicSubscription(ctx, fmt.Sprintf("rds-alert-subscription-%d", i), &sns.TopicSubscriptionArgs{
+			Topic:    alertTopic.Arn,
+			Protocol: pulumi.String("email"),
+			Endpoint: pulumi.String(email),
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	newAlarm := func(name string, metricName string, comparison string, threshold float64, dimensions pulumi.StringMap, description string) error {
+		_, err := cloudwatch.NewMetricAlarm(ctx, name, &cloudwatch.MetricAlarmArgs{
+			Name:               pulumi.String(namer.Name(naming.MetricAlarm, name)),
+			ComparisonOperator: pulumi.String(comparison),
+			EvaluationPeriods:  pulumi.Int(alarms.EvaluationPeriods),
+			MetricName:         pulumi.String(metricName),
+			Namespace:          pulumi.String("AWS/RDS"),
+			Period:             pulumi.Int(alarms.Period),
+			Statistic:          pulumi.String("Average"),
+			Threshold:          pulumi.Float64(threshold),
+			Dimensions:         dimensions,
+			AlarmDescription:   pulumi.String(description),
+			AlarmActions:       pulumi.Array{alertTopic.Arn},
+			OkActions:          pulumi.Array{alertTopic.Arn},
+		}, opts...)
+
+		return err
+	}
+
+	// Aurora publishes its metrics per cluster, a single instance per instance identifier
+	if database.Cluster != nil {
+		clusterDimensions := pulumi.StringMap{"DBClusterIdentifier": database.Cluster.ClusterIdentifier}
+
+		if err = newAlarm("rds-cpu-high", "CPUUtilization", "GreaterThanOrEqualToThreshold", alarms.CPUThreshold, clusterDimensions,
+			fmt.Sprintf("Alarm when database CPU exceeds %v%% threshold", alarms.CPUThreshold)); err != nil {
+			return nil, err
+		}
+
+		if err = newAlarm("rds-connections-high", "DatabaseConnections", "GreaterThanOrEqualToThreshold", alarms.ConnectionsThreshold, clusterDimensions,
+			fmt.Sprintf("Alarm when database connections exceed %v", alarms.ConnectionsThreshold)); err != nil {
+			return nil, err
+		}
+
+		if metadata.Aurora.ReaderCount > 0 {
+			readerDimensions := pulumi.StringMap{
+				"DBClusterIdentifier": database.Cluster.ClusterIdentifier,
+				"Role":                pulumi.String("READER"),
+			}
+
+			if err = newAlarm("rds-replica-lag-high", "AuroraReplicaLag", "GreaterThanOrEqualToThreshold", alarms.ReplicaLagThreshold*1000, readerDimensions,
+				fmt.Sprintf("Alarm when aurora replica lag exceeds %v seconds", alarms.ReplicaLagThreshold)); err != nil {
+				return nil, err
+			}
+		}
+
+		return alertTopic, nil
+	}
+
+	instanceDimensions := pulumi.StringMap{"DBInstanceIdentifier": database.Instance.Identifier}
+
+	if err = newAlarm("rds-cpu-high", "CPUUtilization", "GreaterThanOrEqualToThreshold", alarms.CPUThreshold, instanceDimensions,
+		fmt.Sprintf("Alarm when database CPU exceeds %v%% threshold", alarms.CPUThreshold)); err != nil {
+		return nil, err
+	}
+
+	if err = newAlarm("rds-free-storage-low", "FreeStorageSpace", "LessThanOrEqualToThreshold", alarms.FreeStorageThresholdGiB*1024*1024*1024, instanceDimensions,
+		fmt.Sprintf("Alarm when database free storage falls below %v GiB", alarms.FreeStorageThresholdGiB)); err != nil {
+		return nil, err
+	}
+
+	if err = newAlarm("rds-connections-high", "DatabaseConnections", "GreaterThanOrEqualToThreshold", alarms.ConnectionsThreshold, instanceDimensions,
+		fmt.Sprintf("Alarm when database connections exceed %v", alarms.ConnectionsThreshold)); err != nil {
+		return nil, err
+	}
+
+	for i, readReplica := range database.ReadReplicas {
+		// Metrics of cross region replicas are published in their own region
+		if replicaRegion := metadata.ReadReplicas[i].Region; replicaRegion != "" && replicaRegion != region {
+			continue
+		}
+
+		if err = newAlarm(fmt.Sprintf("rds-replica-lag-high-%d", i), "ReplicaLag", "GreaterThanOrEqualToThreshold", alarms.ReplicaLagThreshold,
+			pulumi.StringMap{"DBInstanceIdentifier": readReplica.Identifier},
+			fmt.Sprintf("Alarm when read replica lag exceeds %v seconds", alarms.ReplicaLagThreshold)); err != nil {
+			return nil, err
+		}
+	}
+
+	return alertTopic, nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetRDSMonitoringDefaults(t *testing.T) {
+	tests := []struct {
+		name          string
+		metadata      RDSInstance
+		engine        string
+		wantRetention int
+		wantErr       string
+	}{
+		{name: "disabled", engine: "postgres"},
+		{name: "performance insights default retention", metadata: RDSInstance{PerformanceInsightsEnabled: true}, engine: "postgres", wantRetention: 7},
+		{name: "retention in months", metadata: RDSInstance{PerformanceInsightsEnabled: true, PerformanceInsightsRetention: 93}, engine: "postgres", wantRetention: 93},
+		{name: "long term retention", metadata: RDSInstance{PerformanceInsightsEnabled: true, PerformanceInsightsRetention: 731}, engine: "postgres", wantRetention: 731},
+		{name: "retention not in months", metadata: RDSInstance{PerformanceInsightsEnabled: true, PerformanceInsightsRetention: 30}, engine: "postgres", wantErr: "retention period 30"},
+		{name: "retention over 713 days", metadata: RDSInstance{PerformanceInsightsEnabled: true, PerformanceInsightsRetention: 744}, engine: "postgres", wantErr: "retention period 744"},
+		{name: "negative retention", metadata: RDSInstance{PerformanceInsightsEnabled: true, PerformanceInsightsRetention: -31}, engine: "postgres", wantErr: "retention period -31"},
+		{name: "monitoring interval", metadata: RDSInstance{MonitoringInterval: 60}, engine: "postgres"},
+		{name: "invalid monitoring interval", metadata: RDSInstance{MonitoringInterval: 20}, engine: "postgres", wantErr: "monitoring interval 20"},
+		{name: "log exports", metadata: RDSInstance{CloudwatchLogsExports: []string{"postgresql", "upgrade"}}, engine: "postgres"},
+		{name: "log export of another engine", metadata: RDSInstance{CloudwatchLogsExports: []string{"slowquery"}}, engine: "postgres", wantErr: `"slowquery" is not supported`},
+		{name: "aurora log exports", metadata: RDSInstance{CloudwatchLogsExports: []string{"upgrade"}}, engine: AuroraPostgresEngine, wantErr: `"upgrade" is not supported`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := tt.metadata
+			err := SetRDSMonitoringDefaults(&metadata, tt.engine)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetRDSMonitoringDefaults() = %v, want an error containing %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SetRDSMonitoringDefaults() = %v, want no error", err)
+			}
+
+			if metadata.PerformanceInsightsRetention != tt.wantRetention {
+				t.Errorf("retention = %d, want %d", metadata.PerformanceInsightsRetention, tt.wantRetention)
+			}
+		})
+	}
+}
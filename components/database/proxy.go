@@ -0,0 +1,220 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/rds"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/secretsmanager"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+// RDSProxyEngineFamily maps the database engine to the engine family supported by RDS Proxy.
+func RDSProxyEngineFamily(engine string) (string, error) {
+	switch engine {
+	case "postgres", AuroraPostgresEngine:
+		return "POSTGRESQL", nil
+	case "mysql", "mariadb":
+		return "MYSQL", nil
+	default:
+		return "", fmt.Errorf("engine %q is not supported by RDS Proxy", engine)
+	}
+}
+
+// NewRDSProxy puts an RDS Proxy in front of the database instance or cluster. The proxy authenticates with credentials
+// stored in Secrets Manager, uses the security group between the app and the database, and replaces the writer
+// host (and the reader host of an Aurora cluster with readers) that is handed to the application.
+func NewRDSProxy(ctx *pulumi.Context, metadata *RDSInstance, namer *naming.Namer, database *RDSDatabase, subnetIDs pulumi.StringArrayInput, proxySecurityGroupID pulumi.IDOutput, opts ...pulumi.ResourceOption) error {
+	proxyConfig := metadata.Proxy
+
+	engine := metadata.Engine
+	if database.Cluster != nil {
+		engine = AuroraPostgresEngine
+	}
+
+	engineFamily, err := RDSProxyEngineFamily(engine)
+	if err != nil {
+		return fmt.Errorf(`{"status": 400, "msg": "%v"}`, err)
+	}
+
+	proxyName := proxyConfig.Name
+	if proxyName == "" {
+		proxyName = fmt.Sprintf("%s-proxy", metadata.Identifier)
+	}
+
+	// Store the database credentials the proxy uses to open connections to the database
+	credentialsSecret, err := secretsmanager.NewSecret(ctx, "rds-proxy-credentials", &secretsmanager.SecretArgs{
+		NamePrefix:  pulumi.String(fmt.Sprintf("%s-credentials-", namer.Existing(naming.RDSProxy, proxyName, proxyName))),
+		Description: pulumi.String("Database credentials used by the webapp RDS Proxy"),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(fmt.Sprintf("%s-credentials", proxyName)),
+		},
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	credentials, err := json.Marshal(map[string]interface{}{
+		"username": metadata.Username,
+		"password": metadata.Password,
+		"engine":   metadata.Engine,
+		"port":     metadata.AllowsPort,
+		"dbname":   metadata.DbName,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = secretsmanager.NewSecretVersion(ctx, "rds-proxy-credentials-version", &secretsmanager.SecretVersionArgs{
+		SecretId:     credentialsSecret.ID(),
+		SecretString: pulumi.ToSecret(pulumi.String(credentials)).(pulumi.StringOutput),
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	// Create IAM role that allows the proxy to read the credentials secret
+	proxyAssumeRolePolicy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect": "Allow",
+				"Action": []string{"sts:AssumeRole"},
+				"Principal": map[string]interface{}{
+					"Service": []string{"rds.amazonaws.com"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	proxyRole, err := iam.NewRole(ctx, "rdsProxyRole", &iam.RoleArgs{
+		AssumeRolePolicy: pulumi.String(proxyAssumeRolePolicy),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(fmt.Sprintf("%s-role", proxyName)),
+		},
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	_, err = iam.NewRolePolicy(ctx, "rdsProxySecretPolicy", &iam.RolePolicyArgs{
+		Role: proxyRole.ID(),
+		Policy: credentialsSecret.Arn.ApplyT(func(secretArn string) (string, error) {
+			policy, err := json.Marshal(map[string]interface{}{
+				"Version": "2012-10-17",
+				"Statement": []map[string]interface{}{
+					{
+						"Effect": "Allow",
+						"Action": []string{
+							"secretsmanager:GetSecretValue",
+							"secretsmanager:DescribeSecret",
+						},
+						"Resource": secretArn,
+					},
+				},
+			})
+
+			return string(policy), err
+		}).(pulumi.StringOutput),
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	requireTLS := true
+	if proxyConfig.RequireTLS != nil {
+		requireTLS = *proxyConfig.RequireTLS
+	}
+
+	idleClientTimeout := proxyConfig.IdleClientTimeout
+	if idleClientTimeout == 0 {
+		idleClientTimeout = 1800
+	}
+
+	proxy, err := rds.NewProxy(ctx, proxyName, &rds.ProxyArgs{
+		Name:                pulumi.String(namer.Existing(naming.RDSProxy, proxyName, proxyName)),
+		EngineFamily:        pulumi.String(engineFamily),
+		RoleArn:             proxyRole.Arn,
+		VpcSubnetIds:        subnetIDs,
+		VpcSecurityGroupIds: pulumi.StringArray{proxySecurityGroupID},
+		RequireTls:          pulumi.Bool(requireTLS),
+		IdleClientTimeout:   pulumi.Int(idleClientTimeout),
+		DebugLogging:        pulumi.Bool(proxyConfig.DebugLogging),
+		Auths: rds.ProxyAuthArray{
+			&rds.ProxyAuthArgs{
+				AuthScheme: pulumi.String("SECRETS"),
+				IamAuth:    pulumi.String("DISABLED"),
+				SecretArn:  credentialsSecret.Arn,
+			},
+		},
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(proxyName),
+		},
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	maxConnectionsPercent := proxyConfig.MaxConnectionsPercent
+	if maxConnectionsPercent == 0 {
+		maxConnectionsPercent = 90
+	}
+
+	if maxConnectionsPercent < 1 || maxConnectionsPercent > 100 {
+		return fmt.Errorf(`{"status": 400, "msg": "Incorrect param proxy.max_connections_percent %d."}`, maxConnectionsPercent)
+	}
+
+	proxyTargetGroup, err := rds.NewProxyDefaultTargetGroup(ctx, fmt.Sprintf("%s-target-group", proxyName), &rds.ProxyDefaultTargetGroupArgs{
+		DbProxyName: proxy.Name,
+		ConnectionPoolConfig: &rds.ProxyDefaultTargetGroupConnectionPoolConfigArgs{
+			MaxConnectionsPercent:   pulumi.Int(maxConnectionsPercent),
+			ConnectionBorrowTimeout: pulumi.Int(120),
+		},
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	// Point the proxy at the cluster in aurora mode and at the primary instance otherwise
+	proxyTargetArgs := &rds.ProxyTargetArgs{
+		DbProxyName:     proxy.Name,
+		TargetGroupName: proxyTargetGroup.Name,
+	}
+
+	if database.Cluster != nil {
+		proxyTargetArgs.DbClusterIdentifier = database.Cluster.ClusterIdentifier
+	} else {
+		proxyTargetArgs.DbInstanceIdentifier = database.Instance.Identifier
+	}
+
+	_, err = rds.NewProxyTarget(ctx, fmt.Sprintf("%s-target", proxyName), proxyTargetArgs, opts...)
+	if err != nil {
+		return err
+	}
+
+	database.Proxy = proxy
+	database.WriterHost = proxy.Endpoint
+
+	// Aurora readers are reached through a read only proxy endpoint
+	if database.Cluster != nil && metadata.Aurora.ReaderCount > 0 {
+		readerEndpoint, err := rds.NewProxyEndpoint(ctx, fmt.Sprintf("%s-reader", proxyName), &rds.ProxyEndpointArgs{
+			DbProxyName:         proxy.Name,
+			DbProxyEndpointName: pulumi.String(fmt.Sprintf("%s-reader", proxyName)),
+			TargetRole:          pulumi.String("READ_ONLY"),
+			VpcSubnetIds:        subnetIDs,
+			VpcSecurityGroupIds: pulumi.StringArray{proxySecurityGroupID},
+		}, opts...)
+		if err != nil {
+			return err
+		}
+
+		database.ReaderHosts = pulumi.StringArray{readerEndpoint.Endpoint}.ToStringArrayOutput()
+	}
+
+	return nil
+}
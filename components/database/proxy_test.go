@@ -0,0 +1,38 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRDSProxyEngineFamily(t *testing.T) {
+	tests := []struct {
+		engine  string
+		want    string
+		wantErr bool
+	}{
+		{engine: "postgres", want: "POSTGRESQL"},
+		{engine: AuroraPostgresEngine, want: "POSTGRESQL"},
+		{engine: "mysql", want: "MYSQL"},
+		{engine: "mariadb", want: "MYSQL"},
+		{engine: "sqlserver-ex", wantErr: true},
+		{engine: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			got, err := RDSProxyEngineFamily(tt.engine)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "not supported by RDS Proxy") {
+					t.Fatalf("RDSProxyEngineFamily() = %q, %v, want an unsupported engine error", got, err)
+				}
+
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("RDSProxyEngineFamily() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
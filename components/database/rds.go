@@ -0,0 +1,460 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/rds"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/encryption"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+const (
+	RDSModeInstance      = "instance"
+	RDSModeAurora        = "aurora"
+	AuroraPostgresEngine = "aurora-postgresql"
+)
+
+// RDSDatabase holds the endpoints of the database tier that the application connects to.
+type RDSDatabase struct {
+	Instance     *rds.Instance
+	ReadReplicas []*rds.Instance
+	Cluster      *rds.Cluster
+	Proxy        *rds.Proxy
+	WriterHost   pulumi.StringOutput
+	ReaderHosts  pulumi.StringArrayOutput
+}
+
+type RDSParameter struct {
+	Value       string `json:"value"`
+	ApplyMethod string `json:"apply_method,omitempty"`
+}
+
+// RDSParameterGroupFamily derives the parameter group family (e.g. postgres15, mysql8.0, mariadb10.6, aurora-postgresql15)
+// from the engine name and engine version of the RDS instance.
+func RDSParameterGroupFamily(engine string, engineVersion string) (string, error) {
+	versionParts := strings.Split(engineVersion, ".")
+	if engineVersion == "" || versionParts[0] == "" {
+		return "", fmt.Errorf("engine version is required to derive parameter group family for %s", engine)
+	}
+
+	for _, part := range versionParts {
+		if _, err := strconv.Atoi(part); err != nil {
+			return "", fmt.Errorf("invalid engine version %q", engineVersion)
+		}
+	}
+
+	major, _ := strconv.Atoi(versionParts[0])
+
+	switch engine {
+	case AuroraPostgresEngine:
+		return fmt.Sprintf("%s%d", engine, major), nil
+	case "postgres":
+		// Since postgres 10 the family only carries the major version, before that it is major.minor (e.g. postgres9.6)
+		if major >= 10 {
+			return fmt.Sprintf("postgres%d", major), nil
+		}
+
+		if len(versionParts) < 2 {
+			return "", fmt.Errorf("invalid engine version %q", engineVersion)
+		}
+
+		return fmt.Sprintf("postgres%s.%s", versionParts[0], versionParts[1]), nil
+	case "mysql", "mariadb":
+		if len(versionParts) < 2 {
+			return "", fmt.Errorf("invalid engine version %q", engineVersion)
+		}
+
+		return fmt.Sprintf("%s%s.%s", engine, versionParts[0], versionParts[1]), nil
+	default:
+		return "", fmt.Errorf("unsupported engine %q", engine)
+	}
+}
+
+// RDSForceSSLParameter returns the engine specific parameter that enforces SSL connections.
+func RDSForceSSLParameter(engine string) string {
+	if engine == "postgres" || engine == AuroraPostgresEngine {
+		return "rds.force_ssl"
+	}
+
+	return "require_secure_transport"
+}
+
+// mergeRDSParameters merges the configured parameters with the force ssl parameter of the engine, validates
+// their apply methods and returns the parameter names sorted so that the parameter group diff is stable between runs.
+func mergeRDSParameters(engine string, forceSSL *bool, configured map[string]RDSParameter) ([]string, map[string]RDSParameter, error) {
+	parameters := make(map[string]RDSParameter, len(configured)+1)
+
+	forceSSLValue := "1"
+	if forceSSL != nil && !*forceSSL {
+		forceSSLValue = "0"
+	}
+
+	parameters[RDSForceSSLParameter(engine)] = RDSParameter{Value: forceSSLValue}
+
+	// Explicitly configured parameters take precedence over the defaults above
+	for name, parameter := range configured {
+		parameters[name] = parameter
+	}
+
+	names := make([]string, 0, len(parameters))
+	for name, parameter := range parameters {
+		if parameter.ApplyMethod == "" {
+			parameter.ApplyMethod = "immediate"
+			parameters[name] = parameter
+		}
+
+		if parameter.ApplyMethod != "immediate" && parameter.ApplyMethod != "pending-reboot" {
+			return nil, nil, fmt.Errorf("invalid apply method %q for parameter %s", parameter.ApplyMethod, name)
+		}
+
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, parameters, nil
+}
+
+// RDSParameterGroupParameters returns the parameters of the instance parameter group, force ssl is on unless disabled in config.
+func RDSParameterGroupParameters(metadata RDSInstance) (rds.ParameterGroupParameterArray, error) {
+	names, parameters, err := mergeRDSParameters(metadata.Engine, metadata.ForceSSL, metadata.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	parameterArray := make(rds.ParameterGroupParameterArray, 0, len(names))
+	for _, name := range names {
+		parameterArray = append(parameterArray, &rds.ParameterGroupParameterArgs{
+			Name:        pulumi.String(name),
+			Value:       pulumi.String(parameters[name].Value),
+			ApplyMethod: pulumi.String(parameters[name].ApplyMethod),
+		})
+	}
+
+	return parameterArray, nil
+}
+
+// RDSClusterParameterGroupParameters returns the parameters of the Aurora cluster parameter group, force ssl is on unless disabled in config.
+func RDSClusterParameterGroupParameters(metadata RDSInstance) (rds.ClusterParameterGroupParameterArray, error) {
+	names, parameters, err := mergeRDSParameters(AuroraPostgresEngine, metadata.ForceSSL, metadata.Aurora.ClusterParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	parameterArray := make(rds.ClusterParameterGroupParameterArray, 0, len(names))
+	for _, name := range names {
+		parameterArray = append(parameterArray, &rds.ClusterParameterGroupParameterArgs{
+			Name:        pulumi.String(name),
+			Value:       pulumi.String(parameters[name].Value),
+			ApplyMethod: pulumi.String(parameters[name].ApplyMethod),
+		})
+	}
+
+	return parameterArray, nil
+}
+
+var (
+	rdsBackupWindowRegex      = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d-([01]\d|2[0-3]):[0-5]\d$`)
+	rdsMaintenanceWindowRegex = regexp.MustCompile(`^(mon|tue|wed|thu|fri|sat|sun):([01]\d|2[0-3]):[0-5]\d-(mon|tue|wed|thu|fri|sat|sun):([01]\d|2[0-3]):[0-5]\d$`)
+	rdsIdentifierInvalidChars = regexp.MustCompile(`[^a-z0-9-]+`)
+	rdsIdentifierHyphens      = regexp.MustCompile(`-{2,}`)
+)
+
+// SetRDSBackupDefaults fills in the backup, maintenance and deletion protection settings that are not configured
+// with production safe defaults and validates the configured ones.
+func SetRDSBackupDefaults(metadata *RDSInstance) error {
+	if metadata.BackupRetentionPeriod == nil {
+		backupRetentionPeriod := 7
+		metadata.BackupRetentionPeriod = &backupRetentionPeriod
+	}
+
+	if *metadata.BackupRetentionPeriod < 0 || *metadata.BackupRetentionPeriod > 35 {
+		return fmt.Errorf("backup retention period must be between 0 and 35 days, got %d", *metadata.BackupRetentionPeriod)
+	}
+
+	if metadata.BackupWindow == "" {
+		metadata.BackupWindow = "03:00-04:00"
+	}
+
+	if !rdsBackupWindowRegex.MatchString(metadata.BackupWindow) {
+		return fmt.Errorf("invalid backup window %q, expected format hh24:mi-hh24:mi", metadata.BackupWindow)
+	}
+
+	if metadata.MaintenanceWindow == "" {
+		metadata.MaintenanceWindow = "sun:04:30-sun:05:30"
+	}
+
+	metadata.MaintenanceWindow = strings.ToLower(metadata.MaintenanceWindow)
+	if !rdsMaintenanceWindowRegex.MatchString(metadata.MaintenanceWindow) {
+		return fmt.Errorf("invalid maintenance window %q, expected format ddd:hh24:mi-ddd:hh24:mi", metadata.MaintenanceWindow)
+	}
+
+	if metadata.DeletionProtection == nil {
+		deletionProtection := true
+		metadata.DeletionProtection = &deletionProtection
+	}
+
+	if metadata.CopyTagsToSnapshot == nil {
+		copyTagsToSnapshot := true
+		metadata.CopyTagsToSnapshot = &copyTagsToSnapshot
+	}
+
+	return nil
+}
+
+// RDSFinalSnapshotIdentifier builds the identifier of the snapshot taken when the instance is deleted
+// from the instance identifier and the stack name (e.g. webapp-db-dev-final).
+func RDSFinalSnapshotIdentifier(identifier string, stack string) string {
+	name := strings.ToLower(fmt.Sprintf("%s-%s-final", identifier, stack))
+
+	// Snapshot identifiers only allow letters, digits and single hyphens and must start with a letter
+	name = rdsIdentifierInvalidChars.ReplaceAllString(name, "-")
+	name = rdsIdentifierHyphens.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-")
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = "snapshot-" + name
+	}
+
+	if len(name) > 255 {
+		// Keep the final suffix and drop characters from the front instead
+		name = strings.TrimLeft(name[len(name)-255:], "-0123456789")
+	}
+
+	return name
+}
+
+// rdsFinalSnapshot returns the final snapshot identifier of the instance or cluster. The default is derived from the
+// identifier and the stack only, so it is the same on every run and stacks created without one pick it up on the
+// next update.
+func rdsFinalSnapshot(ctx *pulumi.Context, metadata *RDSInstance, identifier string) pulumi.StringPtrInput {
+	if !metadata.SkipFinalSnapShot && metadata.FinalSnapshotIdentifier == "" {
+		metadata.FinalSnapshotIdentifier = RDSFinalSnapshotIdentifier(identifier, ctx.Stack())
+	}
+
+	if metadata.FinalSnapshotIdentifier == "" {
+		return nil
+	}
+
+	return pulumi.String(metadata.FinalSnapshotIdentifier)
+}
+
+// NewRDSInstance creates the parameter group, the RDS instance and its read replicas. Replicas in another
+// region are created through a regional provider.
+func NewRDSInstance(ctx *pulumi.Context, metadata *RDSInstance, namer *naming.Namer, region string, subnetGroupName pulumi.StringInput, securityGroupID pulumi.IDOutput, kmsKey *kms.Key, opts ...pulumi.ResourceOption) (*RDSDatabase, error) {
+	// Derive the parameter group family from the engine and its version unless explicitly configured
+	parameterGroupFamily := metadata.ParameterGroupFamily
+	if parameterGroupFamily == "" {
+		family, err := RDSParameterGroupFamily(metadata.Engine, metadata.EngineVersion)
+		if err != nil {
+			return nil, fmt.Errorf(`{"status": 400, "msg": "%v"}`, err)
+		}
+
+		parameterGroupFamily = family
+	}
+
+	// The naming scheme names the group after its family, without it the name stays the one existing stacks have
+	parameterGroupComponent := fmt.Sprintf("rds-%s", strings.ReplaceAll(parameterGroupFamily, ".", "-"))
+	parameterGroupName := metadata.ParameterGroupName
+	if parameterGroupName == "" {
+		parameterGroupName = "webapp-rds-parameter-group"
+	} else {
+		parameterGroupComponent = parameterGroupName
+	}
+
+	parameters, err := RDSParameterGroupParameters(*metadata)
+	if err != nil {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "%v"}`, err)
+	}
+
+	// Create a custom parameter group to configure custom RDS Instance
+	parameterGroup, err := rds.NewParameterGroup(ctx, "webapp-parameter-group", &rds.ParameterGroupArgs{
+		Description: pulumi.String("Custom parameter group for webapp rds instance"),
+		Family:      pulumi.String(parameterGroupFamily),
+		Name:        pulumi.String(namer.Existing(naming.RDSParameterGrp, parameterGroupComponent, parameterGroupName)),
+		Parameters:  parameters,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill in production safe backup, maintenance and deletion protection settings
+	if err = SetRDSBackupDefaults(metadata); err != nil {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "%v"}`, err)
+	}
+
+	if len(metadata.ReadReplicas) > 0 && *metadata.BackupRetentionPeriod == 0 {
+		return nil, errors.New(`{"status": 400, "msg": "Read replicas require a backup retention period greater than 0."}`)
+	}
+
+	if err = validateRDSReadReplicas(metadata.ReadReplicas, region, metadata.StorageEncrypted || kmsKey != nil); err != nil {
+		return nil, err
+	}
+
+	if err = SetRDSMonitoringDefaults(metadata, metadata.Engine); err != nil {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "%v"}`, err)
+	}
+
+	monitoringRoleArn, err := rdsMonitoringRoleArn(ctx, metadata, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	finalSnapshotIdentifier := rdsFinalSnapshot(ctx, metadata, metadata.Identifier)
+
+	// Create the RDS instance with the custom security group and parameter group.
+	instance, err := rds.NewInstance(ctx, metadata.InstanceName, &rds.InstanceArgs{
+		Engine:             pulumi.String(metadata.Engine),
+		EngineVersion:      pulumi.String(metadata.EngineVersion),
+		InstanceClass:      pulumi.String(metadata.InstanceClass),
+		AllocatedStorage:   pulumi.Int(metadata.AllowedStorage),
+		ApplyImmediately:   pulumi.Bool(true),
+		Identifier:         pulumi.String(namer.Existing(naming.RDSInstance, metadata.Identifier, metadata.Identifier)),
+		Username:           pulumi.String(metadata.Username),
+		Password:           pulumi.String(metadata.Password),
+		DbName:             pulumi.String(metadata.DbName),
+		ParameterGroupName: parameterGroup.Name,
+		DbSubnetGroupName:  subnetGroupName,
+		PubliclyAccessible: pulumi.Bool(metadata.PubliclyAccessible),
+		MultiAz:            pulumi.Bool(metadata.MultiAz),
+		SkipFinalSnapshot:  pulumi.Bool(metadata.SkipFinalSnapShot),
+		StorageEncrypted:   pulumi.Bool(metadata.StorageEncrypted || kmsKey != nil),
+		KmsKeyId:           encryption.KMSKeyArn(kmsKey),
+		VpcSecurityGroupIds: pulumi.StringArray{
+			securityGroupID,
+		},
+		BackupRetentionPeriod:   pulumi.Int(*metadata.BackupRetentionPeriod),
+		BackupWindow:            pulumi.String(metadata.BackupWindow),
+		MaintenanceWindow:       pulumi.String(metadata.MaintenanceWindow),
+		DeletionProtection:      pulumi.Bool(*metadata.DeletionProtection),
+		CopyTagsToSnapshot:      pulumi.Bool(*metadata.CopyTagsToSnapshot),
+		FinalSnapshotIdentifier: finalSnapshotIdentifier,
+
+		PerformanceInsightsEnabled:         pulumi.Bool(metadata.PerformanceInsightsEnabled),
+		PerformanceInsightsRetentionPeriod: rdsPerformanceInsightsRetention(metadata),
+		PerformanceInsightsKmsKeyId:        rdsPerformanceInsightsKmsKeyArn(metadata, kmsKey),
+		MonitoringInterval:                 pulumi.Int(metadata.MonitoringInterval),
+		MonitoringRoleArn:                  monitoringRoleArn,
+		EnabledCloudwatchLogsExports:       pulumi.ToStringArray(metadata.CloudwatchLogsExports),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	database := &RDSDatabase{
+		Instance:   instance,
+		WriterHost: instance.Address,
+	}
+
+	var readReplicaAddresses pulumi.StringArray
+	for i, readReplica := range metadata.ReadReplicas {
+		readReplicaIdentifier := readReplica.Identifier
+		if readReplicaIdentifier == "" {
+			readReplicaIdentifier = fmt.Sprintf("%s-replica-%d", metadata.Identifier, i)
+		}
+
+		readReplicaInstanceClass := readReplica.InstanceClass
+		if readReplicaInstanceClass == "" {
+			readReplicaInstanceClass = metadata.InstanceClass
+		}
+
+		readReplicaArgs := &rds.InstanceArgs{
+			Identifier:         pulumi.String(namer.Existing(naming.RDSInstance, readReplicaIdentifier, readReplicaIdentifier)),
+			InstanceClass:      pulumi.String(readReplicaInstanceClass),
+			ApplyImmediately:   pulumi.Bool(true),
+			PubliclyAccessible: pulumi.Bool(false),
+			SkipFinalSnapshot:  pulumi.Bool(true),
+			CopyTagsToSnapshot: pulumi.Bool(*metadata.CopyTagsToSnapshot),
+			MonitoringInterval: pulumi.Int(metadata.MonitoringInterval),
+			MonitoringRoleArn:  monitoringRoleArn,
+			Tags: pulumi.StringMap{
+				"Name": pulumi.String(readReplicaIdentifier),
+			},
+		}
+
+		if readReplica.AvailabilityZone != "" {
+			readReplicaArgs.AvailabilityZone = pulumi.String(readReplica.AvailabilityZone)
+		}
+
+		readReplicaOpts := append([]pulumi.ResourceOption{}, opts...)
+		if readReplica.Region == "" || readReplica.Region == region {
+			// Replicas in the same region share the network and the parameter group of the primary
+			readReplicaArgs.ReplicateSourceDb = instance.Identifier
+			readReplicaArgs.ParameterGroupName = parameterGroup.Name
+			readReplicaArgs.VpcSecurityGroupIds = pulumi.StringArray{securityGroupID}
+		} else {
+			// Cross region replicas reference the primary by ARN and need a subnet group (and KMS key when encrypted) of the target region
+			regionProvider, err := aws.NewProvider(ctx, fmt.Sprintf("rds-replica-provider-%d", i), &aws.ProviderArgs{
+				Region: pulumi.String(readReplica.Region),
+			}, opts...)
+			if err != nil {
+				return nil, err
+			}
+
+			readReplicaArgs.ReplicateSourceDb = instance.Arn
+			readReplicaArgs.DbSubnetGroupName = pulumi.String(readReplica.SubnetGrp)
+
+			if readReplica.KmsKeyID != "" {
+				readReplicaArgs.KmsKeyId = pulumi.String(readReplica.KmsKeyID)
+			}
+
+			readReplicaOpts = append(readReplicaOpts, pulumi.Provider(regionProvider))
+		}
+
+		rdsReadReplica, err := rds.NewInstance(ctx, fmt.Sprintf("%s-replica-%d", metadata.InstanceName, i), readReplicaArgs, readReplicaOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		database.ReadReplicas = append(database.ReadReplicas, rdsReadReplica)
+		readReplicaAddresses = append(readReplicaAddresses, rdsReadReplica.Address)
+	}
+
+	database.ReaderHosts = readReplicaAddresses.ToStringArrayOutput()
+
+	return database, nil
+}
+
+// validateRDSReadReplicas checks that replicas in another region have what they need there: a subnet group, without
+// one the replica lands in the default VPC, and a KMS key of that region when the primary is encrypted.
+func validateRDSReadReplicas(readReplicas []RDSReadReplica, region string, encrypted bool) error {
+	for i, readReplica := range readReplicas {
+		if readReplica.Region == "" || readReplica.Region == region {
+			continue
+		}
+
+		if readReplica.SubnetGrp == "" {
+			return fmt.Errorf(`{"status": 400, "msg": "Read replica %d in %s needs a subnet_group of that region."}`, i, readReplica.Region)
+		}
+
+		if encrypted && readReplica.KmsKeyID == "" {
+			return fmt.Errorf(`{"status": 400, "msg": "Read replica %d in %s of an encrypted primary needs a kms_key_id of that region."}`, i, readReplica.Region)
+		}
+	}
+
+	return nil
+}
+
+// rdsPerformanceInsightsRetention returns the Performance Insights retention period, only set when it is enabled.
+func rdsPerformanceInsightsRetention(metadata *RDSInstance) pulumi.IntPtrInput {
+	if !metadata.PerformanceInsightsEnabled {
+		return nil
+	}
+
+	return pulumi.Int(metadata.PerformanceInsightsRetention)
+}
+
+// rdsPerformanceInsightsKmsKeyArn returns the customer managed key that encrypts Performance Insights data, only set when it is enabled.
+func rdsPerformanceInsightsKmsKeyArn(metadata *RDSInstance, kmsKey *kms.Key) pulumi.StringPtrInput {
+	if !metadata.PerformanceInsightsEnabled {
+		return nil
+	}
+
+	return encryption.KMSKeyArn(kmsKey)
+}
@@ -0,0 +1,243 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/rds"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func TestRDSParameterGroupFamily(t *testing.T) {
+	tests := []struct {
+		engine        string
+		engineVersion string
+		want          string
+		wantErr       string
+	}{
+		{engine: "postgres", engineVersion: "15.4", want: "postgres15"},
+		{engine: "postgres", engineVersion: "16", want: "postgres16"},
+		{engine: "postgres", engineVersion: "9.6.22", want: "postgres9.6"},
+		{engine: "postgres", engineVersion: "9", wantErr: "invalid engine version"},
+		{engine: "mysql", engineVersion: "8.0.35", want: "mysql8.0"},
+		{engine: "mariadb", engineVersion: "10.6.14", want: "mariadb10.6"},
+		{engine: "mysql", engineVersion: "8", wantErr: "invalid engine version"},
+		{engine: AuroraPostgresEngine, engineVersion: "15.4", want: "aurora-postgresql15"},
+		{engine: "postgres", engineVersion: "", wantErr: "engine version is required"},
+		{engine: "postgres", engineVersion: "15.x", wantErr: "invalid engine version"},
+		{engine: "oracle-ee", engineVersion: "19.0", wantErr: "unsupported engine"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine+" "+tt.engineVersion, func(t *testing.T) {
+			got, err := RDSParameterGroupFamily(tt.engine, tt.engineVersion)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RDSParameterGroupFamily() = %q, %v, want an error containing %q", got, err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("RDSParameterGroupFamily() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRDSParameters(t *testing.T) {
+	disabled := false
+
+	tests := []struct {
+		name           string
+		engine         string
+		forceSSL       *bool
+		configured     map[string]RDSParameter
+		wantNames      []string
+		wantParameters map[string]RDSParameter
+		wantErr        string
+	}{
+		{
+			name:           "postgres forces ssl by default",
+			engine:         "postgres",
+			wantNames:      []string{"rds.force_ssl"},
+			wantParameters: map[string]RDSParameter{"rds.force_ssl": {Value: "1", ApplyMethod: "immediate"}},
+		},
+		{
+			name:           "mysql forces ssl by default",
+			engine:         "mysql",
+			wantNames:      []string{"require_secure_transport"},
+			wantParameters: map[string]RDSParameter{"require_secure_transport": {Value: "1", ApplyMethod: "immediate"}},
+		},
+		{
+			name:           "force ssl disabled",
+			engine:         "postgres",
+			forceSSL:       &disabled,
+			wantNames:      []string{"rds.force_ssl"},
+			wantParameters: map[string]RDSParameter{"rds.force_ssl": {Value: "0", ApplyMethod: "immediate"}},
+		},
+		{
+			name:   "configured parameters override force ssl and are sorted",
+			engine: "postgres",
+			configured: map[string]RDSParameter{
+				"rds.force_ssl":    {Value: "0", ApplyMethod: "pending-reboot"},
+				"log_min_duration": {Value: "500"},
+			},
+			wantNames: []string{"log_min_duration", "rds.force_ssl"},
+			wantParameters: map[string]RDSParameter{
+				"log_min_duration": {Value: "500", ApplyMethod: "immediate"},
+				"rds.force_ssl":    {Value: "0", ApplyMethod: "pending-reboot"},
+			},
+		},
+		{
+			name:       "invalid apply method",
+			engine:     "postgres",
+			configured: map[string]RDSParameter{"shared_buffers": {Value: "16384", ApplyMethod: "later"}},
+			wantErr:    `invalid apply method "later"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, parameters, err := mergeRDSParameters(tt.engine, tt.forceSSL, tt.configured)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("mergeRDSParameters() = %v, want an error containing %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("names = %v, want %v", names, tt.wantNames)
+			}
+
+			if !reflect.DeepEqual(parameters, tt.wantParameters) {
+				t.Errorf("parameters = %v, want %v", parameters, tt.wantParameters)
+			}
+		})
+	}
+}
+
+func TestRDSParameterGroupParameters(t *testing.T) {
+	parameters, err := RDSParameterGroupParameters(RDSInstance{
+		Engine:     "postgres",
+		Parameters: map[string]RDSParameter{"rds.force_ssl": {Value: "0"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := rds.ParameterGroupParameterArray{
+		&rds.ParameterGroupParameterArgs{
+			Name:        pulumi.String("rds.force_ssl"),
+			Value:       pulumi.String("0"),
+			ApplyMethod: pulumi.String("immediate"),
+		},
+	}
+	if !reflect.DeepEqual(parameters, want) {
+		t.Errorf("RDSParameterGroupParameters() = %v, want the configured force ssl override", parameters)
+	}
+}
+
+func TestSetRDSBackupDefaults(t *testing.T) {
+	intPtr := func(value int) *int { return &value }
+	boolPtr := func(value bool) *bool { return &value }
+
+	tests := []struct {
+		name     string
+		metadata RDSInstance
+		want     RDSInstance
+		wantErr  string
+	}{
+		{
+			name: "defaults",
+			want: RDSInstance{
+				BackupRetentionPeriod: intPtr(7),
+				BackupWindow:          "03:00-04:00",
+				MaintenanceWindow:     "sun:04:30-sun:05:30",
+				DeletionProtection:    boolPtr(true),
+				CopyTagsToSnapshot:    boolPtr(true),
+			},
+		},
+		{
+			name: "configured",
+			metadata: RDSInstance{
+				BackupRetentionPeriod: intPtr(0),
+				BackupWindow:          "22:30-23:30",
+				MaintenanceWindow:     "Sat:01:00-Sat:02:00",
+				DeletionProtection:    boolPtr(false),
+				CopyTagsToSnapshot:    boolPtr(false),
+			},
+			want: RDSInstance{
+				BackupRetentionPeriod: intPtr(0),
+				BackupWindow:          "22:30-23:30",
+				MaintenanceWindow:     "sat:01:00-sat:02:00",
+				DeletionProtection:    boolPtr(false),
+				CopyTagsToSnapshot:    boolPtr(false),
+			},
+		},
+		{name: "retention over 35 days", metadata: RDSInstance{BackupRetentionPeriod: intPtr(36)}, wantErr: "between 0 and 35 days"},
+		{name: "negative retention", metadata: RDSInstance{BackupRetentionPeriod: intPtr(-1)}, wantErr: "between 0 and 35 days"},
+		{name: "backup window hour out of range", metadata: RDSInstance{BackupWindow: "24:00-01:00"}, wantErr: "invalid backup window"},
+		{name: "backup window without end", metadata: RDSInstance{BackupWindow: "03:00"}, wantErr: "invalid backup window"},
+		{name: "maintenance window without day", metadata: RDSInstance{MaintenanceWindow: "04:30-05:30"}, wantErr: "invalid maintenance window"},
+		{name: "maintenance window unknown day", metadata: RDSInstance{MaintenanceWindow: "sun:04:30-xyz:05:30"}, wantErr: "invalid maintenance window"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := tt.metadata
+			err := SetRDSBackupDefaults(&metadata)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetRDSBackupDefaults() = %v, want an error containing %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(metadata, tt.want) {
+				t.Errorf("SetRDSBackupDefaults() set %+v, want %+v", metadata, tt.want)
+			}
+		})
+	}
+}
+
+func TestRDSFinalSnapshotIdentifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier string
+		stack      string
+		want       string
+	}{
+		{name: "identifier and stack", identifier: "webapp-db", stack: "dev", want: "webapp-db-dev-final"},
+		{name: "invalid characters", identifier: "WebApp_DB", stack: "feature/login", want: "webapp-db-feature-login-final"},
+		{name: "repeated hyphens", identifier: "webapp--db-", stack: "-dev", want: "webapp-db-dev-final"},
+		{name: "starts with a digit", identifier: "1db", stack: "dev", want: "snapshot-1db-dev-final"},
+		{name: "too long", identifier: strings.Repeat("a", 300), stack: "dev", want: strings.Repeat("a", 245) + "-dev-final"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RDSFinalSnapshotIdentifier(tt.identifier, tt.stack)
+			if got != tt.want {
+				t.Errorf("RDSFinalSnapshotIdentifier() = %q, want %q", got, tt.want)
+			}
+
+			if len(got) > 255 || rdsIdentifierInvalidChars.MatchString(got) || rdsIdentifierHyphens.MatchString(got) || got[0] < 'a' || got[0] > 'z' {
+				t.Errorf("RDSFinalSnapshotIdentifier() = %q is not a valid snapshot identifier", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package deployment
+
+import (
+	"fmt"
+)
+
+const (
+	Blue  = "blue"
+	Green = "green"
+)
+
+type Fleet struct {
+	// AmiID is the image the fleet launches, the ami_id of the instance when not set
+	AmiID string `json:"ami_id,omitempty"`
+}
+
+// BlueGreen runs the app on a blue and a green fleet behind the load balancer. The live fleet gets the requests
+// except for the canary weight, which goes to the other fleet.
+type BlueGreen struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Live is the fleet serving production traffic, blue when not set
+	Live string `json:"live,omitempty"`
+	// CanaryWeight is the percentage of requests sent to the fleet that is not live, 0 keeps it out of rotation
+	CanaryWeight int   `json:"canary_weight,omitempty"`
+	Blue         Fleet `json:"blue,omitempty"`
+	Green        Fleet `json:"green,omitempty"`
+}
+
+func (b BlueGreen) Validate() error {
+	if b.Live != "" && b.Live != Blue && b.Live != Green {
+		return fmt.Errorf(`{"status": 400, "msg": "Incorrect param blue_green.live %s, expected blue or green."}`, b.Live)
+	}
+
+	if b.CanaryWeight < 0 || b.CanaryWeight > 100 {
+		return fmt.Errorf(`{"status": 400, "msg": "Incorrect param blue_green.canary_weight %d, expected a percentage."}`, b.CanaryWeight)
+	}
+
+	return nil
+}
+
+func (b BlueGreen) LiveColor() string {
+	if b.Live == "" {
+		return Blue
+	}
+
+	return b.Live
+}
+
+// IdleColor is the fleet that only gets the canary weight, the one a new image is rolled out to.
+func (b BlueGreen) IdleColor() string {
+	if b.LiveColor() == Blue {
+		return Green
+	}
+
+	return Blue
+}
+
+// Weights returns the percentage of requests forwarded to each fleet.
+func (b BlueGreen) Weights() map[string]int {
+	return map[string]int{
+		b.LiveColor(): 100 - b.CanaryWeight,
+		b.IdleColor(): b.CanaryWeight,
+	}
+}
+
+// AmiID returns the image of the fleet of the given color, falling back to the image of the instance.
+func (b BlueGreen) AmiID(color string, defaultAmiID string) string {
+	fleet := b.Blue
+	if color == Green {
+		fleet = b.Green
+	}
+
+	if fleet.AmiID == "" {
+		return defaultAmiID
+	}
+
+	return fleet.AmiID
+}
+
+// Promote makes the idle fleet live and takes the old live fleet out of rotation, so that rolling back is promoting
+// again.
+func (b BlueGreen) Promote() BlueGreen {
+	b.Live = b.IdleColor()
+	b.CanaryWeight = 0
+
+	return b
+}
@@ -0,0 +1,61 @@
+package deployment
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWeights(t *testing.T) {
+	tests := []struct {
+		name      string
+		blueGreen BlueGreen
+		want      map[string]int
+	}{
+		{name: "blue live by default", blueGreen: BlueGreen{}, want: map[string]int{Blue: 100, Green: 0}},
+		{name: "canary on green", blueGreen: BlueGreen{CanaryWeight: 10}, want: map[string]int{Blue: 90, Green: 10}},
+		{name: "canary on blue", blueGreen: BlueGreen{Live: Green, CanaryWeight: 25}, want: map[string]int{Blue: 25, Green: 75}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.blueGreen.Weights(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Weights() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromote(t *testing.T) {
+	blueGreen := BlueGreen{Enabled: true, CanaryWeight: 10, Green: Fleet{AmiID: "ami-new"}}
+
+	promoted := blueGreen.Promote()
+	if promoted.LiveColor() != Green || promoted.CanaryWeight != 0 || promoted.AmiID(Green, "ami-old") != "ami-new" {
+		t.Errorf("Promote() = %+v, want green live without canary", promoted)
+	}
+
+	// Promoting again is the rollback
+	if rolledBack := promoted.Promote(); rolledBack.LiveColor() != Blue || rolledBack.AmiID(Blue, "ami-old") != "ami-old" {
+		t.Errorf("Promote() twice = %+v, want blue live on the image of the instance", rolledBack)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		blueGreen BlueGreen
+		wantErr   string
+	}{
+		{blueGreen: BlueGreen{Live: Green, CanaryWeight: 100}},
+		{blueGreen: BlueGreen{Live: "red"}, wantErr: "expected blue or green"},
+		{blueGreen: BlueGreen{CanaryWeight: 101}, wantErr: "percentage"},
+	} {
+		err := tt.blueGreen.Validate()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("Validate(%+v) = %v, want no error", tt.blueGreen, err)
+		}
+
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Validate(%+v) = %v, want an error containing %q", tt.blueGreen, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/acm"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/route53"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type Certificate struct {
+	// Enabled requests an ACM certificate for the domain instead of using resource_params.certificate_arn
+	Enabled                 bool     `json:"enabled,omitempty"`
+	SubjectAlternativeNames []string `json:"subject_alternative_names,omitempty"`
+}
+
+type CertificateArgs struct {
+	Domain                  string
+	SubjectAlternativeNames []string
+	HostedZoneID            string
+}
+
+// IssuedCertificate is an ACM certificate validated through DNS records in the hosted zone.
+type IssuedCertificate struct {
+	pulumi.ResourceState
+
+	// Arn is only known once the certificate is validated, so a listener using it waits for the validation
+	Arn pulumi.StringOutput
+}
+
+// ValidationDomains returns the domains that need their own validation record, a wildcard shares the record of
+// the domain it covers.
+func ValidationDomains(domain string, subjectAlternativeNames []string) []string {
+	seen := map[string]bool{}
+
+	var domains []string
+	for _, name := range append([]string{domain}, subjectAlternativeNames...) {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if seen[strings.TrimPrefix(name, "*.")] {
+			continue
+		}
+
+		seen[strings.TrimPrefix(name, "*.")] = true
+		domains = append(domains, name)
+	}
+
+	return domains
+}
+
+func NewCertificate(ctx *pulumi.Context, name string, args *CertificateArgs, opts ...pulumi.ResourceOption) (*IssuedCertificate, error) {
+	if args.Domain == "" || args.HostedZoneID == "" {
+		return nil, errors.New(`{"status": 400, "msg": "dns.domain and dns.hosted_zone_id are required to request a certificate."}`)
+	}
+
+	issued := &IssuedCertificate{}
+	err := ctx.RegisterComponentResource("webapp:dns:Certificate", name, issued, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(issued)}
+
+	certificate, err := acm.NewCertificate(ctx, "app-certificate", &acm.CertificateArgs{
+		DomainName:              pulumi.String(args.Domain),
+		SubjectAlternativeNames: pulumi.ToStringArray(args.SubjectAlternativeNames),
+		ValidationMethod:        pulumi.String("DNS"),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The validation options are looked up by domain, ACM doesn't keep them in the order of the request
+	var validationFqdns pulumi.StringArray
+	for i, domain := range ValidationDomains(args.Domain, args.SubjectAlternativeNames) {
+		domain := domain
+		option := certificate.DomainValidationOptions.ApplyT(func(options []acm.CertificateDomainValidationOption) (acm.CertificateDomainValidationOption, error) {
+			for _, option := range options {
+				if option.DomainName != nil && strings.EqualFold(*option.DomainName, domain) {
+					return option, nil
+				}
+			}
+
+			return acm.CertificateDomainValidationOption{}, fmt.Errorf("no validation option for %s", domain)
+		}).(acm.CertificateDomainValidationOptionOutput)
+
+		record, err := route53.NewRecord(ctx, fmt.Sprintf("app-certificate-validation-%d", i), &route53.RecordArgs{
+			Name:           option.ResourceRecordName().Elem(),
+			Type:           option.ResourceRecordType().Elem(),
+			Records:        pulumi.StringArray{option.ResourceRecordValue().Elem()},
+			ZoneId:         pulumi.String(args.HostedZoneID),
+			Ttl:            pulumi.Int(60),
+			AllowOverwrite: pulumi.BoolPtr(true),
+		}, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		validationFqdns = append(validationFqdns, record.Fqdn)
+	}
+
+	validation, err := acm.NewCertificateValidation(ctx, "app-certificate-validation", &acm.CertificateValidationArgs{
+		CertificateArn:        certificate.Arn,
+		ValidationRecordFqdns: validationFqdns,
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	issued.Arn = validation.CertificateArn
+
+	err = ctx.RegisterResourceOutputs(issued, pulumi.Map{
+		"arn": issued.Arn,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issued, nil
+}
@@ -0,0 +1,62 @@
+package dns
+
+import (
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/route53"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type DnsArgs struct {
+	RecordName   string
+	Domain       string
+	Type         string
+	HostedZoneID string
+	// AliasName and AliasZoneID identify the load balancer the record points to
+	AliasName   pulumi.StringInput
+	AliasZoneID pulumi.StringInput
+}
+
+// Dns is the alias record of the app domain in the hosted zone.
+type Dns struct {
+	pulumi.ResourceState
+
+	Fqdn pulumi.StringOutput
+}
+
+func NewDns(ctx *pulumi.Context, name string, args *DnsArgs, opts ...pulumi.ResourceOption) (*Dns, error) {
+	dns := &Dns{}
+	err := ctx.RegisterComponentResource("webapp:dns:Dns", name, dns, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources were created at the top level of the stack before they were grouped, keep their URNs
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(dns), pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}})}
+
+	appRecord, err := route53.NewRecord(ctx, args.RecordName, &route53.RecordArgs{
+		Name:   pulumi.String(args.Domain),
+		Type:   pulumi.String(args.Type),
+		ZoneId: pulumi.String(args.HostedZoneID),
+		Aliases: route53.RecordAliasArray{
+			&route53.RecordAliasArgs{
+				Name:                 args.AliasName,
+				ZoneId:               args.AliasZoneID,
+				EvaluateTargetHealth: pulumi.Bool(true),
+			},
+		},
+		AllowOverwrite: pulumi.BoolPtr(true),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	dns.Fqdn = appRecord.Fqdn
+
+	err = ctx.RegisterResourceOutputs(dns, pulumi.Map{
+		"fqdn": dns.Fqdn,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dns, nil
+}
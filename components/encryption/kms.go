@@ -0,0 +1,272 @@
+package encryption
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+const (
+	KMSServiceRDS  = "rds"
+	KMSServiceEBS  = "ebs"
+	KMSServiceSNS  = "sns"
+	KMSServiceLogs = "logs"
+)
+
+type KMS struct {
+	Enabled              bool   `json:"enabled,omitempty"`
+	KeyPerService        bool   `json:"key_per_service,omitempty"`
+	DeletionWindowInDays int    `json:"deletion_window_in_days,omitempty"`
+	AliasPrefix          string `json:"alias_prefix,omitempty"`
+}
+
+type KMSKeysArgs struct {
+	Config     KMS
+	AccountID  string
+	Region     string
+	EC2RoleArn pulumi.StringInput
+	Namer      *naming.Namer
+}
+
+// KMSKeys holds the customer managed key used by each service, a nil key means the service uses its AWS managed key.
+type KMSKeys struct {
+	pulumi.ResourceState
+
+	RDS  *kms.Key
+	EBS  *kms.Key
+	SNS  *kms.Key
+	Logs *kms.Key
+}
+
+// KMSKeyArn returns the ARN of the key or nil when no customer managed key is configured.
+func KMSKeyArn(key *kms.Key) pulumi.StringPtrInput {
+	if key == nil {
+		return nil
+	}
+
+	return key.Arn
+}
+
+// kmsKeyPolicy builds the key policy that keeps the account in control of the key, lets the EC2 role use it and
+// grants the AWS services of the given list the permissions they need to encrypt with it.
+func kmsKeyPolicy(services []string, accountID string, region string, ec2RoleArn string) (string, error) {
+	keyUsageActions := []string{
+		"kms:Encrypt",
+		"kms:Decrypt",
+		"kms:ReEncrypt*",
+		"kms:GenerateDataKey*",
+		"kms:DescribeKey",
+	}
+
+	statements := []map[string]interface{}{
+		{
+			"Sid":    "EnableIAMUserPermissions",
+			"Effect": "Allow",
+			"Principal": map[string]interface{}{
+				"AWS": fmt.Sprintf("arn:aws:iam::%s:root", accountID),
+			},
+			"Action":   "kms:*",
+			"Resource": "*",
+		},
+		{
+			"Sid":    "AllowEC2RoleUseOfTheKey",
+			"Effect": "Allow",
+			"Principal": map[string]interface{}{
+				"AWS": ec2RoleArn,
+			},
+			"Action":   keyUsageActions,
+			"Resource": "*",
+		},
+	}
+
+	for _, service := range services {
+		switch service {
+		case KMSServiceRDS:
+			statements = append(statements, map[string]interface{}{
+				"Sid":    "AllowRDSUseOfTheKey",
+				"Effect": "Allow",
+				"Principal": map[string]interface{}{
+					"AWS": fmt.Sprintf("arn:aws:iam::%s:root", accountID),
+				},
+				"Action":   append([]string{"kms:CreateGrant", "kms:ListGrants"}, keyUsageActions...),
+				"Resource": "*",
+				"Condition": map[string]interface{}{
+					"StringEquals": map[string]string{
+						"kms:ViaService":    fmt.Sprintf("rds.%s.amazonaws.com", region),
+						"kms:CallerAccount": accountID,
+					},
+				},
+			})
+		case KMSServiceEBS:
+			// Instances launched by the autoscaling group create their volumes through its service linked role
+			autoscalingRoleArn := fmt.Sprintf("arn:aws:iam::%s:role/aws-service-role/autoscaling.amazonaws.com/AWSServiceRoleForAutoScaling", accountID)
+			statements = append(statements, map[string]interface{}{
+				"Sid":    "AllowAutoScalingUseOfTheKey",
+				"Effect": "Allow",
+				"Principal": map[string]interface{}{
+					"AWS": autoscalingRoleArn,
+				},
+				"Action":   keyUsageActions,
+				"Resource": "*",
+			}, map[string]interface{}{
+				"Sid":    "AllowAutoScalingAttachmentOfPersistentResources",
+				"Effect": "Allow",
+				"Principal": map[string]interface{}{
+					"AWS": autoscalingRoleArn,
+				},
+				"Action":   "kms:CreateGrant",
+				"Resource": "*",
+				"Condition": map[string]interface{}{
+					"Bool": map[string]bool{
+						"kms:GrantIsForAWSResource": true,
+					},
+				},
+			})
+		case KMSServiceSNS:
+			// CloudWatch alarms publish to the encrypted topic as well
+			statements = append(statements, map[string]interface{}{
+				"Sid":    "AllowSNSUseOfTheKey",
+				"Effect": "Allow",
+				"Principal": map[string]interface{}{
+					"Service": []string{"sns.amazonaws.com", "cloudwatch.amazonaws.com"},
+				},
+				"Action":   []string{"kms:Decrypt", "kms:GenerateDataKey*"},
+				"Resource": "*",
+			})
+		case KMSServiceLogs:
+			statements = append(statements, map[string]interface{}{
+				"Sid":    "AllowCloudWatchLogsUseOfTheKey",
+				"Effect": "Allow",
+				"Principal": map[string]interface{}{
+					"Service": fmt.Sprintf("logs.%s.amazonaws.com", region),
+				},
+				"Action":   keyUsageActions,
+				"Resource": "*",
+				"Condition": map[string]interface{}{
+					"ArnLike": map[string]string{
+						"kms:EncryptionContext:aws:logs:arn": fmt.Sprintf("arn:aws:logs:%s:%s:*", region, accountID),
+					},
+				},
+			})
+		}
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version":   "2012-10-17",
+		"Statement": statements,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(policy), nil
+}
+
+// NewKMSKeys creates a single customer managed key shared by RDS, EBS, SNS and CloudWatch logs, or one key per
+// service when key_per_service is set. Nothing is created when KMS is disabled in config.
+func NewKMSKeys(ctx *pulumi.Context, name string, args *KMSKeysArgs, opts ...pulumi.ResourceOption) (*KMSKeys, error) {
+	keys := &KMSKeys{}
+	err := ctx.RegisterComponentResource("webapp:encryption:KMSKeys", name, keys, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	kmsConfig := args.Config
+	if !kmsConfig.Enabled {
+		err = ctx.RegisterResourceOutputs(keys, pulumi.Map{})
+		if err != nil {
+			return nil, err
+		}
+
+		return keys, nil
+	}
+
+	// Resources were created at the top level of the stack before they were grouped, keep their URNs
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(keys), pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}})}
+
+	deletionWindowInDays := kmsConfig.DeletionWindowInDays
+	if deletionWindowInDays == 0 {
+		deletionWindowInDays = 30
+	}
+
+	if deletionWindowInDays < 7 || deletionWindowInDays > 30 {
+		return nil, fmt.Errorf(`{"status": 400, "msg": "Incorrect param kms.deletion_window_in_days %d."}`, deletionWindowInDays)
+	}
+
+	aliasPrefix := kmsConfig.AliasPrefix
+	if aliasPrefix == "" {
+		aliasPrefix = "webapp"
+	}
+
+	keyServices := map[string][]string{
+		"shared": {KMSServiceRDS, KMSServiceEBS, KMSServiceSNS, KMSServiceLogs},
+	}
+	if kmsConfig.KeyPerService {
+		keyServices = map[string][]string{
+			KMSServiceRDS:  {KMSServiceRDS},
+			KMSServiceEBS:  {KMSServiceEBS},
+			KMSServiceSNS:  {KMSServiceSNS},
+			KMSServiceLogs: {KMSServiceLogs},
+		}
+	}
+
+	for _, keyName := range []string{"shared", KMSServiceRDS, KMSServiceEBS, KMSServiceSNS, KMSServiceLogs} {
+		services, ok := keyServices[keyName]
+		if !ok {
+			continue
+		}
+
+		policy := args.EC2RoleArn.ToStringOutput().ApplyT(func(roleArn string) (string, error) {
+			return kmsKeyPolicy(services, args.AccountID, args.Region, roleArn)
+		}).(pulumi.StringOutput)
+
+		key, err := kms.NewKey(ctx, fmt.Sprintf("%s-%s-key", aliasPrefix, keyName), &kms.KeyArgs{
+			Description:          pulumi.String(fmt.Sprintf("Customer managed key for webapp %s", keyName)),
+			DeletionWindowInDays: pulumi.Int(deletionWindowInDays),
+			EnableKeyRotation:    pulumi.Bool(true),
+			Policy:               policy,
+			Tags: pulumi.StringMap{
+				"Name": pulumi.String(fmt.Sprintf("%s-%s-key", aliasPrefix, keyName)),
+			},
+		}, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = kms.NewAlias(ctx, fmt.Sprintf("%s-%s-key-alias", aliasPrefix, keyName), &kms.AliasArgs{
+			Name:        pulumi.String(args.Namer.Existing(naming.KMSAlias, fmt.Sprintf("%s-%s", aliasPrefix, keyName), fmt.Sprintf("alias/%s-%s", aliasPrefix, keyName))),
+			TargetKeyId: key.KeyId,
+		}, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, service := range services {
+			switch service {
+			case KMSServiceRDS:
+				keys.RDS = key
+			case KMSServiceEBS:
+				keys.EBS = key
+			case KMSServiceSNS:
+				keys.SNS = key
+			case KMSServiceLogs:
+				keys.Logs = key
+			}
+		}
+	}
+
+	outputs := pulumi.Map{}
+	for service, key := range map[string]*kms.Key{KMSServiceRDS: keys.RDS, KMSServiceEBS: keys.EBS, KMSServiceSNS: keys.SNS, KMSServiceLogs: keys.Logs} {
+		outputs[service+"KeyArn"] = key.Arn
+	}
+
+	err = ctx.RegisterResourceOutputs(keys, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
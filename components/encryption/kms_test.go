@@ -0,0 +1,109 @@
+package encryption
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type policyStatement struct {
+	Sid       string
+	Principal map[string]interface{}
+	Action    interface{}
+	Condition map[string]map[string]interface{}
+}
+
+func TestKMSKeyPolicy(t *testing.T) {
+	const (
+		accountID  = "123456789012"
+		region     = "us-east-1"
+		ec2RoleArn = "arn:aws:iam::123456789012:role/ec2CloudWatchRole"
+	)
+
+	tests := []struct {
+		name     string
+		services []string
+		wantSids []string
+	}{
+		{
+			name:     "no services",
+			wantSids: []string{"EnableIAMUserPermissions", "AllowEC2RoleUseOfTheKey"},
+		},
+		{
+			name:     "shared key",
+			services: []string{KMSServiceRDS, KMSServiceEBS, KMSServiceSNS, KMSServiceLogs},
+			wantSids: []string{
+				"EnableIAMUserPermissions",
+				"AllowEC2RoleUseOfTheKey",
+				"AllowRDSUseOfTheKey",
+				"AllowAutoScalingUseOfTheKey",
+				"AllowAutoScalingAttachmentOfPersistentResources",
+				"AllowSNSUseOfTheKey",
+				"AllowCloudWatchLogsUseOfTheKey",
+			},
+		},
+		{
+			name:     "key of a single service",
+			services: []string{KMSServiceSNS},
+			wantSids: []string{"EnableIAMUserPermissions", "AllowEC2RoleUseOfTheKey", "AllowSNSUseOfTheKey"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := kmsKeyPolicy(tt.services, accountID, region, ec2RoleArn)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var document struct {
+				Version   string
+				Statement []policyStatement
+			}
+			if err = json.Unmarshal([]byte(policy), &document); err != nil {
+				t.Fatal(err)
+			}
+
+			var sids []string
+			statements := make(map[string]policyStatement, len(document.Statement))
+			for _, statement := range document.Statement {
+				sids = append(sids, statement.Sid)
+				statements[statement.Sid] = statement
+			}
+
+			if document.Version != "2012-10-17" || !reflect.DeepEqual(sids, tt.wantSids) {
+				t.Fatalf("policy statements = %v, want %v", sids, tt.wantSids)
+			}
+
+			if got := statements["EnableIAMUserPermissions"].Principal["AWS"]; got != "arn:aws:iam::123456789012:root" {
+				t.Errorf("account principal = %v, want the account root", got)
+			}
+
+			if got := statements["AllowEC2RoleUseOfTheKey"].Principal["AWS"]; got != ec2RoleArn {
+				t.Errorf("EC2 role principal = %v, want %s", got, ec2RoleArn)
+			}
+
+			if rds, ok := statements["AllowRDSUseOfTheKey"]; ok {
+				if got := rds.Condition["StringEquals"]["kms:ViaService"]; got != "rds.us-east-1.amazonaws.com" {
+					t.Errorf("RDS statement is used via %v, want rds.us-east-1.amazonaws.com", got)
+				}
+			}
+
+			if attachment, ok := statements["AllowAutoScalingAttachmentOfPersistentResources"]; ok {
+				if attachment.Action != "kms:CreateGrant" || attachment.Condition["Bool"]["kms:GrantIsForAWSResource"] != true {
+					t.Errorf("autoscaling grant statement = %+v, want CreateGrant for AWS resources only", attachment)
+				}
+			}
+
+			if logs, ok := statements["AllowCloudWatchLogsUseOfTheKey"]; ok {
+				if got := logs.Principal["Service"]; got != "logs.us-east-1.amazonaws.com" {
+					t.Errorf("logs principal = %v, want logs.us-east-1.amazonaws.com", got)
+				}
+
+				if got := logs.Condition["ArnLike"]["kms:EncryptionContext:aws:logs:arn"]; got != "arn:aws:logs:us-east-1:123456789012:*" {
+					t.Errorf("logs encryption context = %v, want the log groups of the account", got)
+				}
+			}
+		})
+	}
+}
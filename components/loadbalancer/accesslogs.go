@@ -0,0 +1,195 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+type AccessLogs struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Prefix is the folder the access logs are written to in the bucket, alb when not set
+	Prefix string `json:"prefix,omitempty"`
+	// ConnectionLogsPrefix is the folder of the connection logs, which record the TLS handshake of each client
+	// connection, alb-connections when not set
+	ConnectionLogsPrefix string `json:"connection_logs_prefix,omitempty"`
+	// ExpirationDays is the number of days access and connection logs are kept, 90 when not set
+	ExpirationDays int `json:"expiration_days,omitempty"`
+	// ForceDestroy lets the stack delete the bucket while it still holds logs
+	ForceDestroy bool `json:"force_destroy,omitempty"`
+}
+
+// elbAccountIDs are the accounts ELB delivers logs from in the regions available before August 2022, newer regions
+// deliver them as the log delivery service.
+var elbAccountIDs = map[string]string{
+	"us-east-1":      "127311923021",
+	"us-east-2":      "033677994240",
+	"us-west-1":      "027434742980",
+	"us-west-2":      "797873946194",
+	"af-south-1":     "098369216593",
+	"ap-east-1":      "754344448648",
+	"ap-southeast-3": "589379963580",
+	"ap-south-1":     "718504428378",
+	"ap-northeast-3": "383597477331",
+	"ap-northeast-2": "600734575887",
+	"ap-southeast-1": "114774131450",
+	"ap-southeast-2": "783225319266",
+	"ap-northeast-1": "582318560864",
+	"ca-central-1":   "985666609251",
+	"eu-central-1":   "054676820928",
+	"eu-west-1":      "156460612806",
+	"eu-west-2":      "652711504416",
+	"eu-south-1":     "635631232127",
+	"eu-west-3":      "009996457667",
+	"eu-north-1":     "897822967062",
+	"me-south-1":     "076674570225",
+	"sa-east-1":      "507241528517",
+	"us-gov-west-1":  "048591011584",
+	"us-gov-east-1":  "190560391635",
+}
+
+// accessLogsBucketPolicy lets ELB of the region write the logs of the account under the prefixes and refuses
+// requests that don't use TLS.
+func accessLogsBucketPolicy(bucketArn string, prefixes []string, accountID string, region string) (string, error) {
+	principal := map[string]interface{}{"Service": "logdelivery.elasticloadbalancing.amazonaws.com"}
+	if elbAccountID, ok := elbAccountIDs[region]; ok {
+		principal = map[string]interface{}{"AWS": fmt.Sprintf("arn:aws:iam::%s:root", elbAccountID)}
+	}
+
+	resources := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		resources = append(resources, fmt.Sprintf("%s/%s/AWSLogs/%s/*", bucketArn, prefix, accountID))
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Sid":       "AllowELBLogDelivery",
+				"Effect":    "Allow",
+				"Principal": principal,
+				"Action":    "s3:PutObject",
+				"Resource":  resources,
+			},
+			{
+				"Sid":       "DenyInsecureTransport",
+				"Effect":    "Deny",
+				"Principal": "*",
+				"Action":    "s3:*",
+				"Resource":  []string{bucketArn, bucketArn + "/*"},
+				"Condition": map[string]interface{}{
+					"Bool": map[string]interface{}{"aws:SecureTransport": "false"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(policy), nil
+}
+
+// newAccessLogsBucket creates the private, encrypted bucket the load balancer writes its access and connection logs
+// to. The bucket policy is returned so that the load balancer waits for it, ELB checks that it can write when logs
+// are enabled.
+func newAccessLogsBucket(ctx *pulumi.Context, config AccessLogs, namer *naming.Namer, accountID string, region string, opts ...pulumi.ResourceOption) (*s3.BucketV2, *s3.BucketPolicy, error) {
+	if config.ExpirationDays < 0 {
+		return nil, nil, fmt.Errorf(`{"status": 400, "msg": "Incorrect param expiration_days %d of the access logs."}`, config.ExpirationDays)
+	}
+
+	if config.Prefix == config.ConnectionLogsPrefix {
+		return nil, nil, fmt.Errorf(`{"status": 400, "msg": "Access logs and connection logs need different prefixes, got %s for both."}`, config.Prefix)
+	}
+
+	// Bucket names are global, the account keeps them unique across accounts using the same project and stack
+	bucket, err := s3.NewBucketV2(ctx, "alb-access-logs", &s3.BucketV2Args{
+		Bucket:       pulumi.String(namer.Name(naming.S3Bucket, fmt.Sprintf("alb-logs-%s", accountID))),
+		ForceDestroy: pulumi.Bool(config.ForceDestroy),
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicAccessBlock, err := s3.NewBucketPublicAccessBlock(ctx, "alb-access-logs", &s3.BucketPublicAccessBlockArgs{
+		Bucket:                bucket.ID(),
+		BlockPublicAcls:       pulumi.Bool(true),
+		BlockPublicPolicy:     pulumi.Bool(true),
+		IgnorePublicAcls:      pulumi.Bool(true),
+		RestrictPublicBuckets: pulumi.Bool(true),
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = s3.NewBucketOwnershipControls(ctx, "alb-access-logs", &s3.BucketOwnershipControlsArgs{
+		Bucket: bucket.ID(),
+		Rule: &s3.BucketOwnershipControlsRuleArgs{
+			ObjectOwnership: pulumi.String("BucketOwnerEnforced"),
+		},
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// ELB only delivers access logs to buckets encrypted with S3 managed keys
+	_, err = s3.NewBucketServerSideEncryptionConfigurationV2(ctx, "alb-access-logs", &s3.BucketServerSideEncryptionConfigurationV2Args{
+		Bucket: bucket.ID(),
+		Rules: s3.BucketServerSideEncryptionConfigurationV2RuleArray{
+			&s3.BucketServerSideEncryptionConfigurationV2RuleArgs{
+				ApplyServerSideEncryptionByDefault: &s3.BucketServerSideEncryptionConfigurationV2RuleApplyServerSideEncryptionByDefaultArgs{
+					SseAlgorithm: pulumi.String("AES256"),
+				},
+			},
+		},
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lifecycleRules s3.BucketLifecycleConfigurationV2RuleArray
+	for _, logs := range []struct{ id, prefix string }{
+		{id: "expire-access-logs", prefix: config.Prefix},
+		{id: "expire-connection-logs", prefix: config.ConnectionLogsPrefix},
+	} {
+		lifecycleRules = append(lifecycleRules, &s3.BucketLifecycleConfigurationV2RuleArgs{
+			Id:     pulumi.String(logs.id),
+			Status: pulumi.String("Enabled"),
+			Filter: &s3.BucketLifecycleConfigurationV2RuleFilterArgs{
+				Prefix: pulumi.String(logs.prefix + "/"),
+			},
+			Expiration: &s3.BucketLifecycleConfigurationV2RuleExpirationArgs{
+				Days: pulumi.Int(config.ExpirationDays),
+			},
+			AbortIncompleteMultipartUpload: &s3.BucketLifecycleConfigurationV2RuleAbortIncompleteMultipartUploadArgs{
+				DaysAfterInitiation: pulumi.Int(1),
+			},
+		})
+	}
+
+	_, err = s3.NewBucketLifecycleConfigurationV2(ctx, "alb-access-logs", &s3.BucketLifecycleConfigurationV2Args{
+		Bucket: bucket.ID(),
+		Rules:  lifecycleRules,
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policy := bucket.Arn.ApplyT(func(bucketArn string) (string, error) {
+		return accessLogsBucketPolicy(bucketArn, []string{config.Prefix, config.ConnectionLogsPrefix}, accountID, region)
+	}).(pulumi.StringOutput)
+
+	// S3 rejects the policy while the public access block is being applied
+	bucketPolicy, err := s3.NewBucketPolicy(ctx, "alb-access-logs", &s3.BucketPolicyArgs{
+		Bucket: bucket.ID(),
+		Policy: policy,
+	}, append(opts, pulumi.DependsOn([]pulumi.Resource{publicAccessBlock}))...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bucket, bucketPolicy, nil
+}
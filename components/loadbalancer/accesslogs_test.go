@@ -0,0 +1,49 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAccessLogsBucketPolicy(t *testing.T) {
+	tests := []struct {
+		region        string
+		wantPrincipal map[string]interface{}
+	}{
+		{region: "eu-west-1", wantPrincipal: map[string]interface{}{"AWS": "arn:aws:iam::156460612806:root"}},
+		{region: "eu-central-2", wantPrincipal: map[string]interface{}{"Service": "logdelivery.elasticloadbalancing.amazonaws.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			policy, err := accessLogsBucketPolicy("arn:aws:s3:::logs", []string{"alb", "alb-connections"}, "123456789012", tt.region)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var document struct {
+				Statement []struct {
+					Principal interface{}
+					Resource  []string
+				}
+			}
+			if err = json.Unmarshal([]byte(policy), &document); err != nil {
+				t.Fatal(err)
+			}
+
+			delivery := document.Statement[0]
+			principal, _ := delivery.Principal.(map[string]interface{})
+			for key, value := range tt.wantPrincipal {
+				if principal[key] != value || len(principal) != 1 {
+					t.Errorf("principal = %v, want %v", delivery.Principal, tt.wantPrincipal)
+				}
+			}
+
+			want := []string{"arn:aws:s3:::logs/alb/AWSLogs/123456789012/*", "arn:aws:s3:::logs/alb-connections/AWSLogs/123456789012/*"}
+			if !reflect.DeepEqual(delivery.Resource, want) {
+				t.Errorf("resource = %v, want the logs of the account under each prefix", delivery.Resource)
+			}
+		})
+	}
+}
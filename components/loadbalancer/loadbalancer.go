@@ -0,0 +1,293 @@
+package loadbalancer
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/deployment"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+type ALB struct {
+	// HTTPRedirect adds a listener on the http port that permanently redirects to https
+	HTTPRedirect bool `json:"http_redirect,omitempty"`
+	// TargetGroup holds the settings of the app target group
+	TargetGroup TargetGroupSettings `json:"target_group,omitempty"`
+	// TargetGroups are created next to the app target group, listener rules route to them by name
+	TargetGroups  []TargetGroup  `json:"target_groups,omitempty"`
+	ListenerRules []ListenerRule `json:"listener_rules,omitempty"`
+	AccessLogs    AccessLogs     `json:"access_logs,omitempty"`
+}
+
+type LoadBalancerArgs struct {
+	Config          ALB
+	VpcID           pulumi.IDOutput
+	SubnetIDs       pulumi.StringArrayInput
+	SecurityGroupID pulumi.IDOutput
+	CertificateArn  pulumi.StringInput
+	// HTTPPort is the port of the redirect listener, 80 when not set
+	HTTPPort int
+	// AppPort is the port the app listens on, 8080 when not set
+	AppPort int
+	// AccountID and Region scope the access logs bucket policy to the load balancers of this account
+	AccountID string
+	Region    string
+	// BlueGreen adds a green target group and splits the requests for the app between blue and green
+	BlueGreen deployment.BlueGreen
+	Namer     *naming.Namer
+}
+
+// LoadBalancer is the internet facing application load balancer that terminates TLS and forwards to the app target
+// group, or to the target group picked by the listener rules.
+type LoadBalancer struct {
+	pulumi.ResourceState
+
+	Arn            pulumi.StringOutput
+	DnsName        pulumi.StringOutput
+	ZoneID         pulumi.StringOutput
+	TargetGroupArn pulumi.StringOutput
+	// TargetGroupArns holds the app target group followed by the configured target groups
+	TargetGroupArns pulumi.StringArrayOutput
+	// GreenTargetGroupArn is the target group of the green fleet, the app target group being the one of the blue fleet
+	GreenTargetGroupArn pulumi.StringOutput
+	// ArnSuffix and the target group ARN suffixes identify them in CloudWatch metrics
+	ArnSuffix                 pulumi.StringOutput
+	TargetGroupArnSuffix      pulumi.StringOutput
+	GreenTargetGroupArnSuffix pulumi.StringOutput
+}
+
+func NewLoadBalancer(ctx *pulumi.Context, name string, args *LoadBalancerArgs, opts ...pulumi.ResourceOption) (*LoadBalancer, error) {
+	loadBalancer := &LoadBalancer{}
+	err := ctx.RegisterComponentResource("webapp:loadbalancer:LoadBalancer", name, loadBalancer, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ValidateListenerRules(args.Config.ListenerRules, args.Config.TargetGroups); err != nil {
+		return nil, err
+	}
+
+	appPort := args.AppPort
+	if appPort == 0 {
+		appPort = 8080
+	}
+
+	appSettings := args.Config.TargetGroup
+	SetTargetGroupDefaults(&appSettings)
+	if err = ValidateTargetGroup(DefaultTargetGroup, appPort, appSettings); err != nil {
+		return nil, err
+	}
+
+	if err = args.BlueGreen.Validate(); err != nil {
+		return nil, err
+	}
+
+	targetGroups := make([]TargetGroup, len(args.Config.TargetGroups))
+	for i, targetGroup := range args.Config.TargetGroups {
+		if args.BlueGreen.Enabled && targetGroup.Name == deployment.Green {
+			return nil, fmt.Errorf(`{"status": 400, "msg": "Target group name %s is used by the green fleet when blue_green is enabled."}`, deployment.Green)
+		}
+
+		SetTargetGroupDefaults(&targetGroup.TargetGroupSettings)
+		if err = ValidateTargetGroup(targetGroup.Name, targetGroup.Port, targetGroup.TargetGroupSettings); err != nil {
+			return nil, err
+		}
+
+		targetGroups[i] = targetGroup
+	}
+
+	// Resources were created at the top level of the stack before they were grouped, keep their URNs
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(loadBalancer), pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}})}
+	newChildOpts := []pulumi.ResourceOption{pulumi.Parent(loadBalancer)}
+
+	loadBalancerArgs := &lb.LoadBalancerArgs{
+		Name:             pulumi.String(args.Namer.Existing(naming.LoadBalancer, "app", "app-load-balancer")),
+		Internal:         pulumi.Bool(false),
+		LoadBalancerType: pulumi.String("application"),
+		SecurityGroups: pulumi.StringArray{
+			args.SecurityGroupID,
+		},
+		Subnets: args.SubnetIDs,
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String("app-load-balancer"),
+		},
+	}
+
+	var accessLogsBucket pulumi.StringOutput
+	loadBalancerOpts := childOpts
+	if accessLogs := args.Config.AccessLogs; accessLogs.Enabled {
+		if accessLogs.Prefix == "" {
+			accessLogs.Prefix = "alb"
+		}
+
+		if accessLogs.ConnectionLogsPrefix == "" {
+			accessLogs.ConnectionLogsPrefix = "alb-connections"
+		}
+
+		if accessLogs.ExpirationDays == 0 {
+			accessLogs.ExpirationDays = 90
+		}
+
+		bucket, bucketPolicy, err := newAccessLogsBucket(ctx, accessLogs, args.Namer, args.AccountID, args.Region, newChildOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		loadBalancerArgs.AccessLogs = &lb.LoadBalancerAccessLogsArgs{
+			Bucket:  bucket.Bucket,
+			Prefix:  pulumi.String(accessLogs.Prefix),
+			Enabled: pulumi.Bool(true),
+		}
+		loadBalancerArgs.ConnectionLogs = &lb.LoadBalancerConnectionLogsArgs{
+			Bucket:  bucket.Bucket,
+			Prefix:  pulumi.String(accessLogs.ConnectionLogsPrefix),
+			Enabled: pulumi.Bool(true),
+		}
+		loadBalancerOpts = append(loadBalancerOpts, pulumi.DependsOn([]pulumi.Resource{bucketPolicy}))
+		accessLogsBucket = bucket.Bucket
+	}
+
+	appLoadBalancer, err := lb.NewLoadBalancer(ctx, "test", loadBalancerArgs, loadBalancerOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	appLoadBalancerTargetGroup, err := lb.NewTargetGroup(ctx, "test", targetGroupArgs(args.Namer.Existing(naming.TargetGroup, "app", "app-loadbalancer-tg"), appPort, appSettings, args.VpcID), childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The app target group serves the blue fleet, the green fleet gets a target group with the same settings
+	defaultActions := lb.ListenerDefaultActionArray{
+		&lb.ListenerDefaultActionArgs{
+			Type:           pulumi.String("forward"),
+			TargetGroupArn: appLoadBalancerTargetGroup.Arn,
+		},
+	}
+
+	var appForward *lb.ListenerRuleActionForwardArgs
+	if args.BlueGreen.Enabled {
+		greenTargetGroup, err := lb.NewTargetGroup(ctx, "green-target-group", targetGroupArgs(args.Namer.Name(naming.TargetGroup, deployment.Green), appPort, appSettings, args.VpcID), newChildOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		weights := args.BlueGreen.Weights()
+		defaultActions = lb.ListenerDefaultActionArray{
+			&lb.ListenerDefaultActionArgs{
+				Type: pulumi.String("forward"),
+				Forward: &lb.ListenerDefaultActionForwardArgs{
+					TargetGroups: lb.ListenerDefaultActionForwardTargetGroupArray{
+						&lb.ListenerDefaultActionForwardTargetGroupArgs{Arn: appLoadBalancerTargetGroup.Arn, Weight: pulumi.Int(weights[deployment.Blue])},
+						&lb.ListenerDefaultActionForwardTargetGroupArgs{Arn: greenTargetGroup.Arn, Weight: pulumi.Int(weights[deployment.Green])},
+					},
+				},
+			},
+		}
+
+		appForward = &lb.ListenerRuleActionForwardArgs{
+			TargetGroups: lb.ListenerRuleActionForwardTargetGroupArray{
+				&lb.ListenerRuleActionForwardTargetGroupArgs{Arn: appLoadBalancerTargetGroup.Arn, Weight: pulumi.Int(weights[deployment.Blue])},
+				&lb.ListenerRuleActionForwardTargetGroupArgs{Arn: greenTargetGroup.Arn, Weight: pulumi.Int(weights[deployment.Green])},
+			},
+		}
+
+		loadBalancer.GreenTargetGroupArn = greenTargetGroup.Arn
+		loadBalancer.GreenTargetGroupArnSuffix = greenTargetGroup.ArnSuffix
+	}
+
+	targetGroupArns := map[string]pulumi.StringOutput{DefaultTargetGroup: appLoadBalancerTargetGroup.Arn}
+	allTargetGroupArns := pulumi.StringArray{appLoadBalancerTargetGroup.Arn}
+	for _, targetGroup := range targetGroups {
+		extraTargetGroup, err := lb.NewTargetGroup(ctx, fmt.Sprintf("%s-target-group", targetGroup.Name), targetGroupArgs(args.Namer.Name(naming.TargetGroup, targetGroup.Name), targetGroup.Port, targetGroup.TargetGroupSettings, args.VpcID), newChildOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		targetGroupArns[targetGroup.Name] = extraTargetGroup.Arn
+		allTargetGroupArns = append(allTargetGroupArns, extraTargetGroup.Arn)
+	}
+
+	httpsListener, err := lb.NewListener(ctx, "frontEndListener", &lb.ListenerArgs{
+		LoadBalancerArn: appLoadBalancer.Arn,
+		Port:            pulumi.Int(443),
+		CertificateArn:  args.CertificateArn,
+		SslPolicy:       pulumi.String("ELBSecurityPolicy-TLS13-1-2-2021-06"),
+		Protocol:        pulumi.String("HTTPS"),
+		DefaultActions:  defaultActions,
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests that match none of the rules go to the default action of the listener, the app target group or blue
+	// and green by weight
+	for _, rule := range args.Config.ListenerRules {
+		_, err = lb.NewListenerRule(ctx, fmt.Sprintf("listener-rule-%s", rule.Name), &lb.ListenerRuleArgs{
+			ListenerArn: httpsListener.Arn,
+			Priority:    pulumi.Int(rule.Priority),
+			Conditions:  listenerRuleConditions(rule.Conditions),
+			Actions:     lb.ListenerRuleActionArray{listenerRuleAction(rule.Action, targetGroupArns, appForward)},
+		}, newChildOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if args.Config.HTTPRedirect {
+		httpPort := args.HTTPPort
+		if httpPort == 0 {
+			httpPort = 80
+		}
+
+		_, err = lb.NewListener(ctx, "httpRedirectListener", &lb.ListenerArgs{
+			LoadBalancerArn: appLoadBalancer.Arn,
+			Port:            pulumi.Int(httpPort),
+			Protocol:        pulumi.String("HTTP"),
+			DefaultActions: lb.ListenerDefaultActionArray{
+				&lb.ListenerDefaultActionArgs{
+					Type: pulumi.String("redirect"),
+					Redirect: &lb.ListenerDefaultActionRedirectArgs{
+						Port:       pulumi.String("443"),
+						Protocol:   pulumi.String("HTTPS"),
+						StatusCode: pulumi.String("HTTP_301"),
+					},
+				},
+			},
+		}, newChildOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	loadBalancer.Arn = appLoadBalancer.Arn
+	loadBalancer.DnsName = appLoadBalancer.DnsName
+	loadBalancer.ZoneID = appLoadBalancer.ZoneId
+	loadBalancer.TargetGroupArn = appLoadBalancerTargetGroup.Arn
+	loadBalancer.TargetGroupArns = allTargetGroupArns.ToStringArrayOutput()
+	loadBalancer.ArnSuffix = appLoadBalancer.ArnSuffix
+	loadBalancer.TargetGroupArnSuffix = appLoadBalancerTargetGroup.ArnSuffix
+
+	outputs := pulumi.Map{
+		"arn":             loadBalancer.Arn,
+		"dnsName":         loadBalancer.DnsName,
+		"zoneId":          loadBalancer.ZoneID,
+		"targetGroupArn":  loadBalancer.TargetGroupArn,
+		"targetGroupArns": loadBalancer.TargetGroupArns,
+	}
+	if args.Config.AccessLogs.Enabled {
+		outputs["accessLogsBucket"] = accessLogsBucket
+	}
+
+	if args.BlueGreen.Enabled {
+		outputs["greenTargetGroupArn"] = loadBalancer.GreenTargetGroupArn
+	}
+
+	err = ctx.RegisterResourceOutputs(loadBalancer, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadBalancer, nil
+}
@@ -0,0 +1,260 @@
+package loadbalancer
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	RuleActionForward       = "forward"
+	RuleActionRedirect      = "redirect"
+	RuleActionFixedResponse = "fixed-response"
+)
+
+// DefaultTargetGroup is the name rules use to forward to the target group of the app, which is created even when no
+// other target groups are configured.
+const DefaultTargetGroup = "app"
+
+// maxRuleConditionValues is the number of values an ALB evaluates per rule across all of its conditions.
+const maxRuleConditionValues = 5
+
+type TargetGroup struct {
+	Name string `json:"name"`
+	Port int    `json:"port,omitempty"`
+	TargetGroupSettings
+}
+
+type HTTPHeaderCondition struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type QueryStringCondition struct {
+	// Key can be left out to match the value of any query parameter
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+type RuleConditions struct {
+	HostHeaders  []string               `json:"host_headers,omitempty"`
+	PathPatterns []string               `json:"path_patterns,omitempty"`
+	HTTPHeaders  []HTTPHeaderCondition  `json:"http_headers,omitempty"`
+	QueryStrings []QueryStringCondition `json:"query_strings,omitempty"`
+}
+
+type RedirectAction struct {
+	Host     string `json:"host,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Port     string `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Query    string `json:"query,omitempty"`
+	// StatusCode is HTTP_301 or HTTP_302, permanent when not set
+	StatusCode string `json:"status_code,omitempty"`
+}
+
+type FixedResponseAction struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	MessageBody string `json:"message_body,omitempty"`
+}
+
+type RuleAction struct {
+	Type string `json:"type"`
+	// TargetGroup is the name of the target group a forward action sends to, the app target group when not set
+	TargetGroup   string               `json:"target_group,omitempty"`
+	Redirect      *RedirectAction      `json:"redirect,omitempty"`
+	FixedResponse *FixedResponseAction `json:"fixed_response,omitempty"`
+}
+
+type ListenerRule struct {
+	Name       string         `json:"name"`
+	Priority   int            `json:"priority"`
+	Conditions RuleConditions `json:"conditions"`
+	Action     RuleAction     `json:"action"`
+}
+
+// ValidateListenerRules checks the rules against the limits of the ALB before anything is created, so that a typo
+// in config doesn't fail the update halfway.
+func ValidateListenerRules(rules []ListenerRule, targetGroups []TargetGroup) error {
+	names := map[string]bool{DefaultTargetGroup: true}
+	for _, targetGroup := range targetGroups {
+		if targetGroup.Name == "" || names[targetGroup.Name] {
+			return fmt.Errorf(`{"status": 400, "msg": "Target group names must be unique and can't be empty or %s, got %q."}`, DefaultTargetGroup, targetGroup.Name)
+		}
+
+		if targetGroup.Port < 1 || targetGroup.Port > 65535 {
+			return fmt.Errorf(`{"status": 400, "msg": "Incorrect param port %d of target group %s."}`, targetGroup.Port, targetGroup.Name)
+		}
+
+		names[targetGroup.Name] = true
+	}
+
+	ruleNames := map[string]bool{}
+	priorities := map[int]string{}
+	for _, rule := range rules {
+		if rule.Name == "" || ruleNames[rule.Name] {
+			return fmt.Errorf(`{"status": 400, "msg": "Listener rule names must be unique and not empty, got %q."}`, rule.Name)
+		}
+		ruleNames[rule.Name] = true
+
+		if rule.Priority < 1 || rule.Priority > 50000 {
+			return fmt.Errorf(`{"status": 400, "msg": "Priority %d of listener rule %s must be between 1 and 50000."}`, rule.Priority, rule.Name)
+		}
+
+		if other, ok := priorities[rule.Priority]; ok {
+			return fmt.Errorf(`{"status": 400, "msg": "Listener rules %s and %s have the same priority %d."}`, other, rule.Name, rule.Priority)
+		}
+		priorities[rule.Priority] = rule.Name
+
+		conditions := rule.Conditions
+		values := len(conditions.HostHeaders) + len(conditions.PathPatterns) + len(conditions.QueryStrings)
+		for _, header := range conditions.HTTPHeaders {
+			if header.Name == "" || len(header.Values) == 0 {
+				return fmt.Errorf(`{"status": 400, "msg": "HTTP header conditions of listener rule %s need a name and values."}`, rule.Name)
+			}
+
+			values += len(header.Values)
+		}
+
+		if values == 0 || values > maxRuleConditionValues {
+			return fmt.Errorf(`{"status": 400, "msg": "Listener rule %s has %d condition values, it needs between 1 and %d."}`, rule.Name, values, maxRuleConditionValues)
+		}
+
+		action := rule.Action
+		switch action.Type {
+		case RuleActionForward:
+			if action.TargetGroup != "" && !names[action.TargetGroup] {
+				return fmt.Errorf(`{"status": 400, "msg": "Listener rule %s forwards to unknown target group %s."}`, rule.Name, action.TargetGroup)
+			}
+		case RuleActionRedirect:
+			if action.Redirect == nil {
+				return fmt.Errorf(`{"status": 400, "msg": "Listener rule %s is missing its redirect."}`, rule.Name)
+			}
+
+			if code := action.Redirect.StatusCode; code != "" && code != "HTTP_301" && code != "HTTP_302" {
+				return fmt.Errorf(`{"status": 400, "msg": "Redirect status code of listener rule %s must be HTTP_301 or HTTP_302, got %s."}`, rule.Name, code)
+			}
+		case RuleActionFixedResponse:
+			if action.FixedResponse == nil {
+				return fmt.Errorf(`{"status": 400, "msg": "Listener rule %s is missing its fixed response."}`, rule.Name)
+			}
+
+			if code := action.FixedResponse.StatusCode; code < 200 || code > 599 || (code >= 300 && code < 400) {
+				return fmt.Errorf(`{"status": 400, "msg": "Fixed response status code %d of listener rule %s must be a 2XX, 4XX or 5XX."}`, code, rule.Name)
+			}
+		default:
+			return fmt.Errorf(`{"status": 400, "msg": "Unsupported action %q of listener rule %s, expected forward, redirect or fixed-response."}`, action.Type, rule.Name)
+		}
+	}
+
+	return nil
+}
+
+func listenerRuleConditions(conditions RuleConditions) lb.ListenerRuleConditionArray {
+	var ruleConditions lb.ListenerRuleConditionArray
+	if len(conditions.HostHeaders) > 0 {
+		ruleConditions = append(ruleConditions, &lb.ListenerRuleConditionArgs{
+			HostHeader: &lb.ListenerRuleConditionHostHeaderArgs{Values: pulumi.ToStringArray(conditions.HostHeaders)},
+		})
+	}
+
+	if len(conditions.PathPatterns) > 0 {
+		ruleConditions = append(ruleConditions, &lb.ListenerRuleConditionArgs{
+			PathPattern: &lb.ListenerRuleConditionPathPatternArgs{Values: pulumi.ToStringArray(conditions.PathPatterns)},
+		})
+	}
+
+	// Every header is a condition of its own, a condition only matches a single header
+	for _, header := range conditions.HTTPHeaders {
+		ruleConditions = append(ruleConditions, &lb.ListenerRuleConditionArgs{
+			HttpHeader: &lb.ListenerRuleConditionHttpHeaderArgs{
+				HttpHeaderName: pulumi.String(header.Name),
+				Values:         pulumi.ToStringArray(header.Values),
+			},
+		})
+	}
+
+	if len(conditions.QueryStrings) > 0 {
+		var queryStrings lb.ListenerRuleConditionQueryStringArray
+		for _, queryString := range conditions.QueryStrings {
+			args := &lb.ListenerRuleConditionQueryStringArgs{Value: pulumi.String(queryString.Value)}
+			if queryString.Key != "" {
+				args.Key = pulumi.String(queryString.Key)
+			}
+
+			queryStrings = append(queryStrings, args)
+		}
+
+		ruleConditions = append(ruleConditions, &lb.ListenerRuleConditionArgs{QueryStrings: queryStrings})
+	}
+
+	return ruleConditions
+}
+
+// listenerRuleAction builds the action of a rule, appForward is the weighted forward to the blue and green target
+// groups that replaces forwarding to the app target group when blue/green is enabled.
+func listenerRuleAction(action RuleAction, targetGroupArns map[string]pulumi.StringOutput, appForward *lb.ListenerRuleActionForwardArgs) *lb.ListenerRuleActionArgs {
+	switch action.Type {
+	case RuleActionRedirect:
+		redirect := action.Redirect
+		statusCode := redirect.StatusCode
+		if statusCode == "" {
+			statusCode = "HTTP_301"
+		}
+
+		return &lb.ListenerRuleActionArgs{
+			Type: pulumi.String(RuleActionRedirect),
+			Redirect: &lb.ListenerRuleActionRedirectArgs{
+				Host:       optionalString(redirect.Host),
+				Path:       optionalString(redirect.Path),
+				Port:       optionalString(redirect.Port),
+				Protocol:   optionalString(redirect.Protocol),
+				Query:      optionalString(redirect.Query),
+				StatusCode: pulumi.String(statusCode),
+			},
+		}
+	case RuleActionFixedResponse:
+		fixedResponse := action.FixedResponse
+		contentType := fixedResponse.ContentType
+		if contentType == "" {
+			contentType = "text/plain"
+		}
+
+		return &lb.ListenerRuleActionArgs{
+			Type: pulumi.String(RuleActionFixedResponse),
+			FixedResponse: &lb.ListenerRuleActionFixedResponseArgs{
+				ContentType: pulumi.String(contentType),
+				MessageBody: optionalString(fixedResponse.MessageBody),
+				StatusCode:  pulumi.String(fmt.Sprint(fixedResponse.StatusCode)),
+			},
+		}
+	default:
+		targetGroup := action.TargetGroup
+		if targetGroup == "" {
+			targetGroup = DefaultTargetGroup
+		}
+
+		if targetGroup == DefaultTargetGroup && appForward != nil {
+			return &lb.ListenerRuleActionArgs{
+				Type:    pulumi.String(RuleActionForward),
+				Forward: appForward,
+			}
+		}
+
+		return &lb.ListenerRuleActionArgs{
+			Type:           pulumi.String(RuleActionForward),
+			TargetGroupArn: targetGroupArns[targetGroup],
+		}
+	}
+}
+
+// optionalString leaves out settings that are not configured so that the ALB keeps its defaults, e.g. #{host}.
+func optionalString(value string) pulumi.StringPtrInput {
+	if value == "" {
+		return nil
+	}
+
+	return pulumi.String(value)
+}
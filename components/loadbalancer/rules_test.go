@@ -0,0 +1,98 @@
+package loadbalancer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateListenerRules(t *testing.T) {
+	targetGroups := []TargetGroup{{Name: "api", Port: 9090}}
+	pathRule := func(name string, priority int, action RuleAction) ListenerRule {
+		return ListenerRule{Name: name, Priority: priority, Conditions: RuleConditions{PathPatterns: []string{"/" + name + "/*"}}, Action: action}
+	}
+
+	tests := []struct {
+		name         string
+		rules        []ListenerRule
+		targetGroups []TargetGroup
+		wantErr      string
+	}{
+		{
+			name: "forward, redirect and fixed response",
+			rules: []ListenerRule{
+				pathRule("api", 10, RuleAction{Type: RuleActionForward, TargetGroup: "api"}),
+				pathRule("app", 20, RuleAction{Type: RuleActionForward}),
+				pathRule("docs", 30, RuleAction{Type: RuleActionRedirect, Redirect: &RedirectAction{Host: "docs.example.com"}}),
+				pathRule("maintenance", 40, RuleAction{Type: RuleActionFixedResponse, FixedResponse: &FixedResponseAction{StatusCode: 503}}),
+			},
+			targetGroups: targetGroups,
+		},
+		{
+			name:         "duplicate priority",
+			rules:        []ListenerRule{pathRule("api", 10, RuleAction{Type: RuleActionForward}), pathRule("admin", 10, RuleAction{Type: RuleActionForward})},
+			targetGroups: targetGroups,
+			wantErr:      "same priority",
+		},
+		{
+			name:    "priority out of range",
+			rules:   []ListenerRule{pathRule("api", 50001, RuleAction{Type: RuleActionForward})},
+			wantErr: "between 1 and 50000",
+		},
+		{
+			name:    "unknown target group",
+			rules:   []ListenerRule{pathRule("admin", 10, RuleAction{Type: RuleActionForward, TargetGroup: "admin"})},
+			wantErr: "unknown target group",
+		},
+		{
+			name:    "no conditions",
+			rules:   []ListenerRule{{Name: "all", Priority: 1, Action: RuleAction{Type: RuleActionForward}}},
+			wantErr: "condition values",
+		},
+		{
+			name: "too many condition values",
+			rules: []ListenerRule{{Name: "many", Priority: 1, Action: RuleAction{Type: RuleActionForward}, Conditions: RuleConditions{
+				PathPatterns: []string{"/a", "/b", "/c"},
+				HTTPHeaders:  []HTTPHeaderCondition{{Name: "X-Env", Values: []string{"dev", "test", "stage"}}},
+			}}},
+			wantErr: "condition values",
+		},
+		{
+			name:    "redirect without target",
+			rules:   []ListenerRule{pathRule("docs", 1, RuleAction{Type: RuleActionRedirect})},
+			wantErr: "missing its redirect",
+		},
+		{
+			name:    "temporary redirect status code",
+			rules:   []ListenerRule{pathRule("docs", 1, RuleAction{Type: RuleActionRedirect, Redirect: &RedirectAction{StatusCode: "HTTP_307"}})},
+			wantErr: "HTTP_301 or HTTP_302",
+		},
+		{
+			name:    "fixed response with a redirect status code",
+			rules:   []ListenerRule{pathRule("down", 1, RuleAction{Type: RuleActionFixedResponse, FixedResponse: &FixedResponseAction{StatusCode: 301}})},
+			wantErr: "2XX, 4XX or 5XX",
+		},
+		{
+			name:    "unsupported action",
+			rules:   []ListenerRule{pathRule("auth", 1, RuleAction{Type: "authenticate-oidc"})},
+			wantErr: "Unsupported action",
+		},
+		{
+			name:         "target group named like the app target group",
+			targetGroups: []TargetGroup{{Name: DefaultTargetGroup, Port: 8080}},
+			wantErr:      "must be unique",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateListenerRules(tt.rules, tt.targetGroups)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ValidateListenerRules() = %v, want no error", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ValidateListenerRules() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type HealthCheck struct {
+	Path string `json:"path,omitempty"`
+	// Port is traffic-port or the port of the target group
+	Port     string `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	// Matcher is the HTTP codes of a healthy target, e.g. 200 or 200-299
+	Matcher            string `json:"matcher,omitempty"`
+	HealthyThreshold   int    `json:"healthy_threshold,omitempty"`
+	UnhealthyThreshold int    `json:"unhealthy_threshold,omitempty"`
+	Timeout            int    `json:"timeout,omitempty"`
+	Interval           int    `json:"interval,omitempty"`
+}
+
+type Stickiness struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Type is lb_cookie or app_cookie, app_cookie needs the name of the cookie the app sets
+	Type           string `json:"type,omitempty"`
+	CookieName     string `json:"cookie_name,omitempty"`
+	CookieDuration int    `json:"cookie_duration,omitempty"`
+}
+
+type TargetGroupSettings struct {
+	Protocol    string      `json:"protocol,omitempty"`
+	HealthCheck HealthCheck `json:"health_check,omitempty"`
+	// DeregistrationDelay is the seconds in-flight requests get to finish, 300 when not set
+	DeregistrationDelay *int `json:"deregistration_delay,omitempty"`
+	// SlowStart is the seconds a new target takes to get its full share of requests, 0 disables it
+	SlowStart              int        `json:"slow_start,omitempty"`
+	LoadBalancingAlgorithm string     `json:"load_balancing_algorithm,omitempty"`
+	Stickiness             Stickiness `json:"stickiness,omitempty"`
+}
+
+// SetTargetGroupDefaults fills in the settings the app target group was created with before they were configurable.
+func SetTargetGroupDefaults(settings *TargetGroupSettings) {
+	if settings.Protocol == "" {
+		settings.Protocol = "HTTP"
+	}
+
+	healthCheck := &settings.HealthCheck
+	if healthCheck.Path == "" {
+		healthCheck.Path = "/healthz"
+	}
+
+	if healthCheck.Port == "" {
+		healthCheck.Port = "traffic-port"
+	}
+
+	if healthCheck.Protocol == "" {
+		healthCheck.Protocol = settings.Protocol
+	}
+
+	if healthCheck.HealthyThreshold == 0 {
+		healthCheck.HealthyThreshold = 2
+	}
+
+	if healthCheck.UnhealthyThreshold == 0 {
+		healthCheck.UnhealthyThreshold = 2
+	}
+
+	if healthCheck.Timeout == 0 {
+		healthCheck.Timeout = 3
+	}
+
+	if healthCheck.Interval == 0 {
+		healthCheck.Interval = 30
+	}
+
+	if settings.Stickiness.Enabled && settings.Stickiness.Type == "" {
+		settings.Stickiness.Type = "lb_cookie"
+	}
+
+	if settings.Stickiness.Enabled && settings.Stickiness.CookieDuration == 0 {
+		settings.Stickiness.CookieDuration = 86400
+	}
+}
+
+// ValidateTargetGroup checks the settings of a target group listening on port against the limits of the ALB.
+func ValidateTargetGroup(name string, port int, settings TargetGroupSettings) error {
+	healthCheck := settings.HealthCheck
+
+	if port < 1 || port > 65535 {
+		return fmt.Errorf(`{"status": 400, "msg": "Incorrect param port %d of target group %s."}`, port, name)
+	}
+
+	if healthCheck.Port != "traffic-port" && healthCheck.Port != strconv.Itoa(port) {
+		return fmt.Errorf(`{"status": 400, "msg": "Health check port %s of target group %s must be traffic-port or the app port %d."}`, healthCheck.Port, name, port)
+	}
+
+	if healthCheck.Interval < 5 || healthCheck.Interval > 300 {
+		return fmt.Errorf(`{"status": 400, "msg": "Health check interval %d of target group %s must be between 5 and 300 seconds."}`, healthCheck.Interval, name)
+	}
+
+	if healthCheck.Timeout < 2 || healthCheck.Timeout >= healthCheck.Interval {
+		return fmt.Errorf(`{"status": 400, "msg": "Health check timeout %d of target group %s must be at least 2 seconds and less than the interval %d."}`, healthCheck.Timeout, name, healthCheck.Interval)
+	}
+
+	for _, threshold := range []int{healthCheck.HealthyThreshold, healthCheck.UnhealthyThreshold} {
+		if threshold < 2 || threshold > 10 {
+			return fmt.Errorf(`{"status": 400, "msg": "Health check thresholds of target group %s must be between 2 and 10, got %d."}`, name, threshold)
+		}
+	}
+
+	if delay := settings.DeregistrationDelay; delay != nil && (*delay < 0 || *delay > 3600) {
+		return fmt.Errorf(`{"status": 400, "msg": "Deregistration delay %d of target group %s must be between 0 and 3600 seconds."}`, *delay, name)
+	}
+
+	if settings.SlowStart != 0 && (settings.SlowStart < 30 || settings.SlowStart > 900) {
+		return fmt.Errorf(`{"status": 400, "msg": "Slow start %d of target group %s must be 0 or between 30 and 900 seconds."}`, settings.SlowStart, name)
+	}
+
+	switch settings.LoadBalancingAlgorithm {
+	case "", "round_robin":
+	case "least_outstanding_requests":
+		if settings.SlowStart != 0 {
+			return fmt.Errorf(`{"status": 400, "msg": "Slow start of target group %s can't be used with least_outstanding_requests."}`, name)
+		}
+	default:
+		return fmt.Errorf(`{"status": 400, "msg": "Unsupported load balancing algorithm %s of target group %s."}`, settings.LoadBalancingAlgorithm, name)
+	}
+
+	if stickiness := settings.Stickiness; stickiness.Enabled {
+		if stickiness.Type != "lb_cookie" && stickiness.Type != "app_cookie" {
+			return fmt.Errorf(`{"status": 400, "msg": "Stickiness type %s of target group %s must be lb_cookie or app_cookie."}`, stickiness.Type, name)
+		}
+
+		if stickiness.Type == "app_cookie" && stickiness.CookieName == "" {
+			return fmt.Errorf(`{"status": 400, "msg": "App cookie stickiness of target group %s needs the cookie_name."}`, name)
+		}
+
+		if stickiness.CookieDuration < 1 || stickiness.CookieDuration > 604800 {
+			return fmt.Errorf(`{"status": 400, "msg": "Stickiness cookie duration %d of target group %s must be between 1 second and 7 days."}`, stickiness.CookieDuration, name)
+		}
+	}
+
+	return nil
+}
+
+// targetGroupArgs builds the target group from settings that have their defaults set and are validated.
+func targetGroupArgs(name string, port int, settings TargetGroupSettings, vpcID pulumi.IDOutput) *lb.TargetGroupArgs {
+	healthCheck := settings.HealthCheck
+
+	args := &lb.TargetGroupArgs{
+		Name:       pulumi.String(name),
+		Port:       pulumi.Int(port),
+		Protocol:   pulumi.String(settings.Protocol),
+		TargetType: pulumi.String("instance"),
+		HealthCheck: lb.TargetGroupHealthCheckArgs{
+			Enabled:            pulumi.Bool(true),
+			Path:               pulumi.String(healthCheck.Path),
+			Port:               pulumi.String(healthCheck.Port),
+			Protocol:           pulumi.String(healthCheck.Protocol),
+			Matcher:            optionalString(healthCheck.Matcher),
+			HealthyThreshold:   pulumi.Int(healthCheck.HealthyThreshold),
+			UnhealthyThreshold: pulumi.Int(healthCheck.UnhealthyThreshold),
+			Timeout:            pulumi.Int(healthCheck.Timeout),
+			Interval:           pulumi.Int(healthCheck.Interval),
+		},
+		VpcId: vpcID,
+	}
+
+	if settings.DeregistrationDelay != nil {
+		args.DeregistrationDelay = pulumi.Int(*settings.DeregistrationDelay)
+	}
+
+	if settings.SlowStart != 0 {
+		args.SlowStart = pulumi.Int(settings.SlowStart)
+	}
+
+	if settings.LoadBalancingAlgorithm != "" {
+		args.LoadBalancingAlgorithmType = pulumi.String(settings.LoadBalancingAlgorithm)
+	}
+
+	if stickiness := settings.Stickiness; stickiness.Enabled {
+		stickinessArgs := &lb.TargetGroupStickinessArgs{
+			Enabled:        pulumi.Bool(true),
+			Type:           pulumi.String(stickiness.Type),
+			CookieDuration: pulumi.Int(stickiness.CookieDuration),
+		}
+
+		if stickiness.Type == "app_cookie" {
+			stickinessArgs.CookieName = pulumi.String(stickiness.CookieName)
+		}
+
+		args.Stickiness = stickinessArgs
+	}
+
+	return args
+}
@@ -0,0 +1,97 @@
+package loadbalancer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTargetGroup(t *testing.T) {
+	intPtr := func(value int) *int { return &value }
+
+	tests := []struct {
+		name     string
+		port     int
+		settings TargetGroupSettings
+		wantErr  string
+	}{
+		{
+			name: "defaults",
+			port: 8080,
+		},
+		{
+			name: "all settings",
+			port: 8080,
+			settings: TargetGroupSettings{
+				HealthCheck:            HealthCheck{Port: "8080", Matcher: "200-299", HealthyThreshold: 3, Timeout: 5, Interval: 10},
+				DeregistrationDelay:    intPtr(0),
+				SlowStart:              60,
+				LoadBalancingAlgorithm: "round_robin",
+				Stickiness:             Stickiness{Enabled: true, Type: "app_cookie", CookieName: "session"},
+			},
+		},
+		{
+			name:     "timeout not shorter than the interval",
+			port:     8080,
+			settings: TargetGroupSettings{HealthCheck: HealthCheck{Timeout: 10, Interval: 10}},
+			wantErr:  "less than the interval",
+		},
+		{
+			name:     "health check on another port",
+			port:     8080,
+			settings: TargetGroupSettings{HealthCheck: HealthCheck{Port: "9090"}},
+			wantErr:  "traffic-port or the app port 8080",
+		},
+		{
+			name:     "threshold out of range",
+			port:     8080,
+			settings: TargetGroupSettings{HealthCheck: HealthCheck{UnhealthyThreshold: 11}},
+			wantErr:  "between 2 and 10",
+		},
+		{
+			name:     "deregistration delay out of range",
+			port:     8080,
+			settings: TargetGroupSettings{DeregistrationDelay: intPtr(3601)},
+			wantErr:  "between 0 and 3600",
+		},
+		{
+			name:     "slow start too short",
+			port:     8080,
+			settings: TargetGroupSettings{SlowStart: 10},
+			wantErr:  "between 30 and 900",
+		},
+		{
+			name:     "slow start with least outstanding requests",
+			port:     8080,
+			settings: TargetGroupSettings{SlowStart: 60, LoadBalancingAlgorithm: "least_outstanding_requests"},
+			wantErr:  "least_outstanding_requests",
+		},
+		{
+			name:     "unsupported algorithm",
+			port:     8080,
+			settings: TargetGroupSettings{LoadBalancingAlgorithm: "weighted_random"},
+			wantErr:  "Unsupported load balancing algorithm",
+		},
+		{
+			name:     "app cookie without a name",
+			port:     8080,
+			settings: TargetGroupSettings{Stickiness: Stickiness{Enabled: true, Type: "app_cookie"}},
+			wantErr:  "cookie_name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := tt.settings
+			SetTargetGroupDefaults(&settings)
+
+			err := ValidateTargetGroup("app", tt.port, settings)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ValidateTargetGroup() = %v, want no error", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ValidateTargetGroup() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
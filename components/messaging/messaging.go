@@ -0,0 +1,144 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sns"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/encryption"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+type MessagingArgs struct {
+	TopicName string
+	Region    string
+	AccountID string
+	KmsKey    *kms.Key
+	// PublisherRoleID is the IAM role that is allowed to publish to the topic
+	PublisherRoleID pulumi.IDOutput
+	Namer           *naming.Namer
+}
+
+// Messaging is the SNS topic the app publishes submissions to and the IAM policy that lets the app publish.
+type Messaging struct {
+	pulumi.ResourceState
+
+	TopicArn pulumi.StringOutput
+}
+
+func NewMessaging(ctx *pulumi.Context, name string, args *MessagingArgs, opts ...pulumi.ResourceOption) (*Messaging, error) {
+	messaging := &Messaging{}
+	err := ctx.RegisterComponentResource("webapp:messaging:Messaging", name, messaging, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources were created at the top level of the stack before they were grouped, keep their URNs
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(messaging), pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}})}
+
+	topicName := args.Namer.Existing(naming.SNSTopic, args.TopicName, args.TopicName)
+	topicArn := fmt.Sprintf("arn:aws:sns:%v:%v:%v", args.Region, args.AccountID, topicName)
+
+	snsAccessPolicy, err := json.Marshal(map[string]interface{}{
+		"Version": "2008-10-17",
+		"Id":      "__default_policy_ID",
+		"Statement": []map[string]interface{}{
+			{
+				"Sid":    "__default_statement_ID",
+				"Effect": "Allow",
+				"Principal": map[string]string{
+					"AWS": "*",
+				},
+				"Action": []string{
+					"SNS:Publish",
+					"SNS:RemovePermission",
+					"SNS:SetTopicAttributes",
+					"SNS:DeleteTopic",
+					"SNS:ListSubscriptionsByTopic",
+					"SNS:GetTopicAttributes",
+					"SNS:AddPermission",
+					"SNS:Subscribe",
+				},
+				"Resource": topicArn,
+				"Condition": map[string]interface{}{
+					"StringEquals": map[string]string{
+						"AWS:SourceOwner": args.AccountID,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snsTopic, err := sns.NewTopic(ctx, "testSNSTopic", &sns.TopicArgs{
+		Name:           pulumi.String(topicName),
+		DisplayName:    pulumi.String("submissions"),
+		FifoTopic:      pulumi.Bool(false),
+		Policy:         pulumi.String(snsAccessPolicy),
+		KmsMasterKeyId: encryption.KMSKeyArn(args.KmsKey),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	snsPolicyStr, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Sid":    "VisualEditor0",
+				"Effect": "Allow",
+				"Action": []string{
+					"sns:Publish",
+					"sns:Subscribe",
+				},
+				"Resource": topicArn,
+			},
+			{
+				"Sid": "VisualEditor1",
+				"Action": []string{
+					"sns:ListTopics",
+					"sns:Unsubscribe",
+					"sns:ListSubscriptions",
+				},
+				"Effect":   "Allow",
+				"Resource": "*",
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	customSNSPolicy, err := iam.NewPolicy(ctx, "CustomSNSPolicy2", &iam.PolicyArgs{
+		Path:        pulumi.String("/"),
+		Description: pulumi.String("Custom SNS policy to publish message from EC2 to SNS Topic"),
+		Policy:      pulumi.String(snsPolicyStr),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = iam.NewRolePolicyAttachment(ctx, "ec2SNSPolicy", &iam.RolePolicyAttachmentArgs{
+		Role:      args.PublisherRoleID,
+		PolicyArn: customSNSPolicy.Arn,
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	messaging.TopicArn = snsTopic.Arn
+
+	err = ctx.RegisterResourceOutputs(messaging, pulumi.Map{
+		"topicArn": messaging.TopicArn,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messaging, nil
+}
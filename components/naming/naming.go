@@ -0,0 +1,146 @@
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Service holds the constraints AWS puts on the physical names of a kind of resource.
+type Service struct {
+	MaxLength int
+	// Invalid matches the characters the service doesn't accept, they are replaced by hyphens
+	Invalid   *regexp.Regexp
+	Lowercase bool
+	// Prefix is part of the name but not of the identifier the user picks, e.g. alias/ for KMS aliases
+	Prefix string
+}
+
+var (
+	LoadBalancer     = Service{MaxLength: 32, Invalid: regexp.MustCompile(`[^a-zA-Z0-9-]+`)}
+	TargetGroup      = Service{MaxLength: 32, Invalid: regexp.MustCompile(`[^a-zA-Z0-9-]+`)}
+	LaunchTemplate   = Service{MaxLength: 128, Invalid: regexp.MustCompile(`[^a-zA-Z0-9()./_-]+`)}
+	AutoScalingGroup = Service{MaxLength: 255, Invalid: regexp.MustCompile(`[^a-zA-Z0-9._-]+`)}
+	MetricAlarm      = Service{MaxLength: 255, Invalid: regexp.MustCompile(`[^a-zA-Z0-9._-]+`)}
+	KeyPair          = Service{MaxLength: 255, Invalid: regexp.MustCompile(`[^a-zA-Z0-9._-]+`)}
+	SNSTopic         = Service{MaxLength: 256, Invalid: regexp.MustCompile(`[^a-zA-Z0-9_-]+`)}
+	RDSInstance      = Service{MaxLength: 63, Invalid: regexp.MustCompile(`[^a-z0-9-]+`), Lowercase: true}
+	RDSCluster       = Service{MaxLength: 63, Invalid: regexp.MustCompile(`[^a-z0-9-]+`), Lowercase: true}
+	RDSParameterGrp  = Service{MaxLength: 255, Invalid: regexp.MustCompile(`[^a-z0-9-]+`), Lowercase: true}
+	RDSSubnetGroup   = Service{MaxLength: 255, Invalid: regexp.MustCompile(`[^a-z0-9._-]+`), Lowercase: true}
+	RDSProxy         = Service{MaxLength: 60, Invalid: regexp.MustCompile(`[^a-z0-9-]+`), Lowercase: true}
+	S3Bucket         = Service{MaxLength: 63, Invalid: regexp.MustCompile(`[^a-z0-9.-]+`), Lowercase: true}
+	KMSAlias         = Service{MaxLength: 256, Invalid: regexp.MustCompile(`[^a-zA-Z0-9/_-]+`), Prefix: "alias/"}
+	WAFWebACL        = Service{MaxLength: 128, Invalid: regexp.MustCompile(`[^a-zA-Z0-9_-]+`)}
+	WAFIPSet         = Service{MaxLength: 128, Invalid: regexp.MustCompile(`[^a-zA-Z0-9_-]+`)}
+	WAFLogGroup      = Service{MaxLength: 512, Invalid: regexp.MustCompile(`[^a-zA-Z0-9_./#-]+`), Prefix: "aws-waf-logs-"}
+)
+
+// hashLength is the number of hex characters appended to names that had to be shortened.
+const hashLength = 6
+
+var repeatedHyphens = regexp.MustCompile(`-{2,}`)
+
+type Config struct {
+	// Enabled names the resources that existed before the naming scheme with it too, which replaces them on stacks
+	// that were deployed with their old names
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// Namer builds the physical names of resources so that stacks deployed to the same account don't collide.
+type Namer struct {
+	project string
+	stack   string
+	region  string
+	// renameExisting is set when the naming scheme is enabled for the resources that existed before it
+	renameExisting bool
+}
+
+func New(project string, stack string, region string) *Namer {
+	return &Namer{project: project, stack: stack, region: region}
+}
+
+func NewFromContext(ctx *pulumi.Context, region string, config Config) *Namer {
+	namer := New(ctx.Project(), ctx.Stack(), region)
+	namer.renameExisting = config.Enabled
+
+	return namer
+}
+
+// Existing names a resource that existed before the naming scheme. It keeps the name it was created with, e.g. a
+// name from config, unless the naming scheme is enabled.
+func (n *Namer) Existing(service Service, component string, name string) string {
+	if !n.renameExisting {
+		return name
+	}
+
+	return n.Name(service, component)
+}
+
+// AutoNamed names a resource that existed before the naming scheme without a name, Pulumi gave it one with a random
+// suffix. It keeps that name unless the naming scheme is enabled.
+func (n *Namer) AutoNamed(service Service, component string) pulumi.StringPtrInput {
+	if !n.renameExisting {
+		return nil
+	}
+
+	return pulumi.String(n.Name(service, component))
+}
+
+// Name returns <project>-<stack>-<region>-<component> made valid for the service. The region is shortened, e.g.
+// us-east-1 to use1, and names over the length limit of the service are cut and end with a hash of the full name
+// to stay unique.
+func (n *Namer) Name(service Service, component string) string {
+	parts := make([]string, 0, 4)
+	for _, part := range []string{n.project, n.stack, RegionCode(n.region), component} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	name := strings.Join(parts, "-")
+	if service.Lowercase {
+		name = strings.ToLower(name)
+	}
+
+	if service.Invalid != nil {
+		name = service.Invalid.ReplaceAllString(name, "-")
+	}
+
+	// RDS doesn't accept consecutive or trailing hyphens, nothing else needs them either
+	name = strings.Trim(repeatedHyphens.ReplaceAllString(name, "-"), "-")
+
+	maxLength := service.MaxLength - len(service.Prefix)
+	if maxLength > 0 && len(name) > maxLength {
+		sum := sha256.Sum256([]byte(name))
+		name = strings.TrimRight(name[:maxLength-hashLength-1], "-") + "-" + hex.EncodeToString(sum[:])[:hashLength]
+	}
+
+	return service.Prefix + name
+}
+
+// RegionCode shortens an AWS region to its first part and the initials of the rest, e.g. eu-central-1 to euc1.
+func RegionCode(region string) string {
+	parts := strings.Split(region, "-")
+	if len(parts) < 2 {
+		return region
+	}
+
+	code := parts[0]
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+
+		if part[0] >= '0' && part[0] <= '9' {
+			code += part
+		} else {
+			code += part[:1]
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,118 @@
+package naming
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name      string
+		namer     *Namer
+		service   Service
+		component string
+		want      string
+	}{
+		{
+			name:      "load balancer",
+			namer:     New("iac-pulumi", "dev", "us-east-1"),
+			service:   LoadBalancer,
+			component: "app",
+			want:      "iac-pulumi-dev-use1-app",
+		},
+		{
+			name:      "invalid characters are replaced",
+			namer:     New("iac-pulumi", "dev", "us-east-1"),
+			service:   TargetGroup,
+			component: "app_tg.v2",
+			want:      "iac-pulumi-dev-use1-app-tg-v2",
+		},
+		{
+			name:      "rds identifiers are lowercase without repeated hyphens",
+			namer:     New("iac-pulumi", "Dev", "eu-central-1"),
+			service:   RDSInstance,
+			component: "-webapp--DB-",
+			want:      "iac-pulumi-dev-euc1-webapp-db",
+		},
+		{
+			name:      "long names are cut and keep a hash",
+			namer:     New("iac-pulumi", "feature-very-long-branch-name", "ap-southeast-2"),
+			service:   LoadBalancer,
+			component: "app",
+			want:      "iac-pulumi-feature-very-l-cac235",
+		},
+		{
+			name:      "kms alias prefix",
+			namer:     New("iac-pulumi", "dev", "us-west-2"),
+			service:   KMSAlias,
+			component: "webapp-rds",
+			want:      "alias/iac-pulumi-dev-usw2-webapp-rds",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.namer.Name(tt.service, tt.component)
+			if len(got) > tt.service.MaxLength {
+				t.Errorf("Name() = %q is %d characters, over the limit of %d", got, len(got), tt.service.MaxLength)
+			}
+
+			if got != tt.want {
+				t.Errorf("Name() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameTruncationIsUnique(t *testing.T) {
+	namer := New("iac-pulumi", "feature-very-long-branch-name", "us-east-1")
+
+	first := namer.Name(TargetGroup, "blue")
+	second := namer.Name(TargetGroup, "green")
+	if first == second {
+		t.Errorf("different components got the same shortened name %q", first)
+	}
+
+	if len(first) != TargetGroup.MaxLength || len(second) != TargetGroup.MaxLength {
+		t.Errorf("got %q and %q, want names of %d characters", first, second, TargetGroup.MaxLength)
+	}
+}
+
+func TestExisting(t *testing.T) {
+	namer := New("iac-pulumi", "dev", "us-east-1")
+	if got := namer.Existing(RDSInstance, "webapp-db", "webapp-db"); got != "webapp-db" {
+		t.Errorf("Existing() = %q without the naming scheme, want the name it was created with", got)
+	}
+
+	namer.renameExisting = true
+	if got := namer.Existing(RDSInstance, "webapp-db", "webapp-db"); got != "iac-pulumi-dev-use1-webapp-db" {
+		t.Errorf("Existing() = %q with the naming scheme, want iac-pulumi-dev-use1-webapp-db", got)
+	}
+}
+
+func TestAutoNamed(t *testing.T) {
+	namer := New("iac-pulumi", "dev", "us-east-1")
+	if got := namer.AutoNamed(RDSSubnetGroup, "webapp-db-subnet-group"); got != nil {
+		t.Errorf("AutoNamed() = %v without the naming scheme, want no name", got)
+	}
+
+	namer.renameExisting = true
+	if got, ok := namer.AutoNamed(RDSSubnetGroup, "webapp-db-subnet-group").(pulumi.String); !ok || got != "iac-pulumi-dev-use1-webapp-db-subnet-group" {
+		t.Errorf("AutoNamed() = %v with the naming scheme, want iac-pulumi-dev-use1-webapp-db-subnet-group", got)
+	}
+}
+
+func TestRegionCode(t *testing.T) {
+	for region, want := range map[string]string{
+		"us-east-1":      "use1",
+		"eu-central-1":   "euc1",
+		"ap-southeast-2": "aps2",
+		"us-gov-west-1":  "usgw1",
+		"":               "",
+	} {
+		if got := RegionCode(region); got != want {
+			t.Errorf("RegionCode(%q) = %q, want %q", region, got, want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type NetworkArgs struct {
+	VpcName                       string
+	CidrBlock                     string
+	InstanceTenancy               string
+	MaxAvailabilityZones          int
+	BitsToMask                    int
+	InternetGatewayName           string
+	InternetGatewayAttachmentName string
+	PublicRouteName               string
+	PublicRouteTableName          string
+	PrivateRouteTableName         string
+	PublicDestinationCidr         string
+	PublicSubnetsPrefix           string
+	PrivateSubnetsPrefix          string
+	PublicRouteTableAssocPrefix   string
+	PrivateRouteTableAssocPrefix  string
+}
+
+// Network is a VPC with one public and one private subnet per availability zone, the public subnets route
+// to the internet through an internet gateway.
+type Network struct {
+	pulumi.ResourceState
+
+	VpcID                pulumi.IDOutput
+	AvailabilityZones    []string
+	PublicSubnetIDs      pulumi.StringArrayOutput
+	PrivateSubnetIDs     pulumi.StringArrayOutput
+	PublicSubnetIDsByAz  pulumi.StringMapOutput
+	PrivateSubnetIDsByAz pulumi.StringMapOutput
+}
+
+func NewNetwork(ctx *pulumi.Context, name string, args *NetworkArgs, opts ...pulumi.ResourceOption) (*Network, error) {
+	network := &Network{}
+	err := ctx.RegisterComponentResource("webapp:network:Network", name, network, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources were created at the top level of the stack before they were grouped, keep their URNs
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(network), pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}})}
+
+	// Create a VPC
+	awsVpc, err := ec2.NewVpc(ctx, args.VpcName, &ec2.VpcArgs{
+		CidrBlock:       pulumi.String(args.CidrBlock),
+		InstanceTenancy: pulumi.String(args.InstanceTenancy),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(args.VpcName),
+		},
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for availability zones based on the region
+	availableZones, err := aws.GetAvailabilityZones(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validation on MaxAvailabilityZones
+	if args.MaxAvailabilityZones == 0 || args.MaxAvailabilityZones > len(availableZones.Names) {
+		return nil, errors.New(`{"status": 400, "msg": "Not sufficient AvailabilityZones"}`)
+	}
+
+	// Validation on BitsToMask
+	if args.BitsToMask == 0 || args.BitsToMask > 32 {
+		return nil, errors.New(`{"status": 400, "msg": "Incorrect param bits_to_mask."}`)
+	}
+
+	// Assign AvailabilityZones based on our config requirements
+	network.AvailabilityZones = availableZones.Names[0:args.MaxAvailabilityZones]
+
+	// Calculate subnet cidr given VPC cidr, number of subnets required and bits to mask.
+	subnets, err := CalculateCIDRSubnets(args.CidrBlock, len(network.AvailabilityZones)*2, args.BitsToMask)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create InternetGateway
+	awsIGW, err := ec2.NewInternetGateway(ctx, args.InternetGatewayName, &ec2.InternetGatewayArgs{
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(args.InternetGatewayName),
+		},
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach InternetGateway to VPC
+	_, err = ec2.NewInternetGatewayAttachment(ctx, args.InternetGatewayAttachmentName, &ec2.InternetGatewayAttachmentArgs{
+		InternetGatewayId: awsIGW.ID(),
+		VpcId:             awsVpc.ID(),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	publicSubnets := pulumi.StringArray{}
+	privateSubnets := pulumi.StringArray{}
+	publicSubnetsByAz := pulumi.StringMap{}
+	privateSubnetsByAz := pulumi.StringMap{}
+
+	// Iterate over cidrBlocksPublic to create a public subnets in each availability zones under an existing vpc
+	noOfAvailabilityZones := len(network.AvailabilityZones)
+	for i := 0; i < noOfAvailabilityZones; i++ {
+		cidr := subnets[i]
+		subnetName := fmt.Sprintf(args.PublicSubnetsPrefix+"-%d", i)
+		publicSubnet, err := ec2.NewSubnet(ctx, subnetName, &ec2.SubnetArgs{
+			VpcId:            awsVpc.ID(),
+			AvailabilityZone: pulumi.String(network.AvailabilityZones[i]),
+			CidrBlock:        pulumi.String(cidr),
+			Tags: pulumi.StringMap{
+				"Name": pulumi.String(subnetName),
+			},
+		}, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		publicSubnets = append(publicSubnets, publicSubnet.ID())
+		publicSubnetsByAz[network.AvailabilityZones[i]] = publicSubnet.ID()
+	}
+
+	// Iterate over cidrBlocksPrivate to create a private subnets in each availability zones under an existing vpc
+	for i := 0; i < noOfAvailabilityZones; i++ {
+		cidr := subnets[noOfAvailabilityZones+i]
+		subnetName := fmt.Sprintf(args.PrivateSubnetsPrefix+"-%d", i)
+		privateSubnet, err := ec2.NewSubnet(ctx, subnetName, &ec2.SubnetArgs{
+			VpcId:            awsVpc.ID(),
+			AvailabilityZone: pulumi.String(network.AvailabilityZones[i]),
+			CidrBlock:        pulumi.String(cidr),
+			Tags: pulumi.StringMap{
+				"Name": pulumi.String(subnetName),
+			},
+		}, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		privateSubnets = append(privateSubnets, privateSubnet.ID())
+		privateSubnetsByAz[network.AvailabilityZones[i]] = privateSubnet.ID()
+	}
+
+	// Create a public route table to an existing vpc
+	publicRouteTable, err := ec2.NewRouteTable(ctx, args.PublicRouteTableName, &ec2.RouteTableArgs{
+		VpcId: awsVpc.ID(),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(args.PublicRouteTableName),
+		},
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a private route table to an existing vpc
+	privateRouteTable, err := ec2.NewRouteTable(ctx, args.PrivateRouteTableName, &ec2.RouteTableArgs{
+		VpcId: awsVpc.ID(),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(args.PrivateRouteTableName),
+		},
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a route for the public route table to an Internet Gateway (for public subnets)
+	_, err = ec2.NewRoute(ctx, args.PublicRouteName, &ec2.RouteArgs{
+		RouteTableId:         publicRouteTable.ID(),
+		DestinationCidrBlock: pulumi.String(args.PublicDestinationCidr),
+		GatewayId:            awsIGW.ID(),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Associate the public route table with public subnets
+	for i, publicSubnetID := range publicSubnets {
+		_, err := ec2.NewRouteTableAssociation(ctx, fmt.Sprintf(args.PublicRouteTableAssocPrefix+"-%d", i), &ec2.RouteTableAssociationArgs{
+			SubnetId:     publicSubnetID,
+			RouteTableId: publicRouteTable.ID(),
+		}, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create separate route tables for private subnets
+	for i, privateSubnetID := range privateSubnets {
+		// Associate each private route table with its respective private subnet
+		_, err = ec2.NewRouteTableAssociation(ctx, fmt.Sprintf(args.PrivateRouteTableAssocPrefix+"-%d", i), &ec2.RouteTableAssociationArgs{
+			SubnetId:     privateSubnetID,
+			RouteTableId: privateRouteTable.ID(),
+		}, childOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	network.VpcID = awsVpc.ID()
+	network.PublicSubnetIDs = publicSubnets.ToStringArrayOutput()
+	network.PrivateSubnetIDs = privateSubnets.ToStringArrayOutput()
+	network.PublicSubnetIDsByAz = publicSubnetsByAz.ToStringMapOutput()
+	network.PrivateSubnetIDsByAz = privateSubnetsByAz.ToStringMapOutput()
+
+	err = ctx.RegisterResourceOutputs(network, pulumi.Map{
+		"vpcId":                network.VpcID,
+		"publicSubnetIds":      network.PublicSubnetIDs,
+		"privateSubnetIds":     network.PrivateSubnetIDs,
+		"publicSubnetIdsByAz":  network.PublicSubnetIDsByAz,
+		"privateSubnetIdsByAz": network.PrivateSubnetIDsByAz,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return network, nil
+}
@@ -0,0 +1,65 @@
+package network
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+)
+
+// CalculateCIDRSubnets splits the parent CIDR into numSubnets consecutive subnets with a prefix length of bitsToMask,
+// starting at the first address of the parent. Works for both IPv4 and IPv6 parents.
+func CalculateCIDRSubnets(parentCIDR string, numSubnets int, bitsToMask int) ([]string, error) {
+	// Parse the parent CIDR into an IPNet struct
+	_, parentIPNet, err := net.ParseCIDR(parentCIDR)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate the new subnet mask
+	parentPrefixLen, maskLen := parentIPNet.Mask.Size()
+	if bitsToMask > maskLen {
+		return nil, fmt.Errorf("Bits to mask exceeds the available bits in the parent CIDR")
+	}
+
+	if bitsToMask < parentPrefixLen {
+		return nil, fmt.Errorf("Bits to mask %d is smaller than the prefix length %d of the parent CIDR", bitsToMask, parentPrefixLen)
+	}
+
+	if numSubnets < 0 {
+		return nil, fmt.Errorf("Number of subnets %d can't be negative", numSubnets)
+	}
+
+	// The parent only fits 2^(bitsToMask-parentPrefixLen) subnets of the requested size
+	availableSubnets := new(big.Int).Lsh(big.NewInt(1), uint(bitsToMask-parentPrefixLen))
+	if big.NewInt(int64(numSubnets)).Cmp(availableSubnets) > 0 {
+		return nil, fmt.Errorf("%d subnets of /%d don't fit in the parent CIDR %s", numSubnets, bitsToMask, parentIPNet)
+	}
+
+	// Create new CIDR subnets
+	subnets := make([]string, numSubnets)
+	subnetSize := new(big.Int).Lsh(big.NewInt(1), uint(maskLen-bitsToMask))
+	subnetIP := parentIPNet.IP
+
+	for i := 0; i < numSubnets; i++ {
+		// netip keeps IPv4-mapped IPv6 addresses in their IPv6 form so the prefix length stays valid
+		subnetAddr, _ := netip.AddrFromSlice(subnetIP)
+		subnets[i] = netip.PrefixFrom(subnetAddr, bitsToMask).String()
+
+		// The subnet after the last one may lie past the end of the address space, don't compute it
+		if i < numSubnets-1 {
+			subnetIP = nextSubnetIP(subnetIP, subnetSize)
+		}
+	}
+
+	return subnets, nil
+}
+
+func nextSubnetIP(ip net.IP, subnetSize *big.Int) net.IP {
+	// Calculate the next subnet IP by adding the subnet size
+	bigIP := new(big.Int).SetBytes(ip)
+	bigIP.Add(bigIP, subnetSize)
+
+	// Pad the bytes to match the IP length
+	return bigIP.FillBytes(make(net.IP, len(ip)))
+}
@@ -0,0 +1,208 @@
+package network
+
+import (
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestCalculateCIDRSubnets(t *testing.T) {
+	tests := []struct {
+		name       string
+		parentCIDR string
+		numSubnets int
+		bitsToMask int
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "vpc split into /24 subnets",
+			parentCIDR: "10.0.0.0/16",
+			numSubnets: 4,
+			bitsToMask: 24,
+			want:       []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"},
+		},
+		{
+			name:       "host bits of the parent are ignored",
+			parentCIDR: "10.0.12.34/16",
+			numSubnets: 2,
+			bitsToMask: 24,
+			want:       []string{"10.0.0.0/24", "10.0.1.0/24"},
+		},
+		{
+			name:       "subnets crossing an octet boundary",
+			parentCIDR: "172.16.0.0/12",
+			numSubnets: 3,
+			bitsToMask: 14,
+			want:       []string{"172.16.0.0/14", "172.20.0.0/14", "172.24.0.0/14"},
+		},
+		{
+			name:       "subnets smaller than an octet",
+			parentCIDR: "192.168.1.0/24",
+			numSubnets: 4,
+			bitsToMask: 26,
+			want:       []string{"192.168.1.0/26", "192.168.1.64/26", "192.168.1.128/26", "192.168.1.192/26"},
+		},
+		{
+			name:       "subnet of the same size as the parent",
+			parentCIDR: "10.1.0.0/16",
+			numSubnets: 1,
+			bitsToMask: 16,
+			want:       []string{"10.1.0.0/16"},
+		},
+		{
+			name:       "parent completely filled up to the end of the address space",
+			parentCIDR: "255.255.255.0/24",
+			numSubnets: 4,
+			bitsToMask: 26,
+			want:       []string{"255.255.255.0/26", "255.255.255.64/26", "255.255.255.128/26", "255.255.255.192/26"},
+		},
+		{
+			name:       "single host subnets",
+			parentCIDR: "10.0.0.0/30",
+			numSubnets: 4,
+			bitsToMask: 32,
+			want:       []string{"10.0.0.0/32", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32"},
+		},
+		{
+			name:       "no subnets",
+			parentCIDR: "10.0.0.0/16",
+			numSubnets: 0,
+			bitsToMask: 24,
+			want:       []string{},
+		},
+		{
+			name:       "ipv6 vpc split into /64 subnets",
+			parentCIDR: "2600:1f18:abcd:1200::/56",
+			numSubnets: 3,
+			bitsToMask: 64,
+			want:       []string{"2600:1f18:abcd:1200::/64", "2600:1f18:abcd:1201::/64", "2600:1f18:abcd:1202::/64"},
+		},
+		{
+			name:       "ipv6 subnets crossing a group boundary",
+			parentCIDR: "2001:db8::/32",
+			numSubnets: 2,
+			bitsToMask: 33,
+			want:       []string{"2001:db8::/33", "2001:db8:8000::/33"},
+		},
+		{
+			name:       "invalid parent",
+			parentCIDR: "10.0.0.0",
+			numSubnets: 2,
+			bitsToMask: 24,
+			wantErr:    true,
+		},
+		{
+			name:       "bits to mask larger than the address",
+			parentCIDR: "10.0.0.0/16",
+			numSubnets: 2,
+			bitsToMask: 33,
+			wantErr:    true,
+		},
+		{
+			name:       "subnets larger than the parent",
+			parentCIDR: "10.0.0.0/16",
+			numSubnets: 2,
+			bitsToMask: 8,
+			wantErr:    true,
+		},
+		{
+			name:       "more subnets than fit in the parent",
+			parentCIDR: "10.0.0.0/24",
+			numSubnets: 5,
+			bitsToMask: 26,
+			wantErr:    true,
+		},
+		{
+			name:       "negative number of subnets",
+			parentCIDR: "10.0.0.0/16",
+			numSubnets: -1,
+			bitsToMask: 24,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculateCIDRSubnets(tt.parentCIDR, tt.numSubnets, tt.bitsToMask)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateCIDRSubnets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CalculateCIDRSubnets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// lastAddress returns the last address of the network as an integer.
+func lastAddress(network *net.IPNet) *big.Int {
+	ones, bits := network.Mask.Size()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+
+	return size.Add(size, new(big.Int).SetBytes(network.IP)).Sub(size, big.NewInt(1))
+}
+
+func FuzzCalculateCIDRSubnets(f *testing.F) {
+	f.Add("10.0.0.0/16", 6, 24)
+	f.Add("192.168.0.0/24", 4, 26)
+	f.Add("255.255.255.0/24", 256, 32)
+	f.Add("0.0.0.0/0", 3, 2)
+	f.Add("2600:1f18:abcd:1200::/56", 8, 64)
+	f.Add("2001:db8::/32", 2, 33)
+	f.Add("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ff00/120", 16, 124)
+
+	f.Fuzz(func(t *testing.T, parentCIDR string, numSubnets int, bitsToMask int) {
+		// Keep the allocations of a single run small
+		if numSubnets > 1024 {
+			t.Skip()
+		}
+
+		subnets, err := CalculateCIDRSubnets(parentCIDR, numSubnets, bitsToMask)
+		if err != nil {
+			return
+		}
+
+		_, parent, err := net.ParseCIDR(parentCIDR)
+		if err != nil {
+			t.Fatalf("CalculateCIDRSubnets(%q) accepted an invalid parent", parentCIDR)
+		}
+
+		if len(subnets) != numSubnets {
+			t.Fatalf("got %d subnets, want %d", len(subnets), numSubnets)
+		}
+
+		parentLast := lastAddress(parent)
+		var previousLast *big.Int
+		for _, subnetCIDR := range subnets {
+			subnetIP, subnet, err := net.ParseCIDR(subnetCIDR)
+			if err != nil {
+				t.Fatalf("subnet %q is not a valid CIDR: %v", subnetCIDR, err)
+			}
+
+			// Every subnet has the requested prefix and starts on its network address
+			if ones, bits := subnet.Mask.Size(); ones != bitsToMask || len(subnet.IP)*8 != bits || len(parent.IP) != len(subnet.IP) {
+				t.Fatalf("subnet %s has prefix /%d of a %d bit address, want /%d of the parent family", subnetCIDR, ones, bits, bitsToMask)
+			}
+
+			if !subnetIP.Equal(subnet.IP) {
+				t.Fatalf("subnet %s does not start on its network address %s", subnetCIDR, subnet.IP)
+			}
+
+			// Every subnet lies inside the parent
+			if !parent.Contains(subnet.IP) || lastAddress(subnet).Cmp(parentLast) > 0 {
+				t.Fatalf("subnet %s is not inside the parent %s", subnetCIDR, parent)
+			}
+
+			// Subnets increase monotonically and don't overlap their predecessor
+			first := new(big.Int).SetBytes(subnet.IP)
+			if previousLast != nil && first.Cmp(previousLast) <= 0 {
+				t.Fatalf("subnet %s overlaps or precedes the previous subnet in %v", subnetCIDR, subnets)
+			}
+
+			previousLast = lastAddress(subnet)
+		}
+	})
+}
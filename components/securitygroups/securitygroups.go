@@ -0,0 +1,284 @@
+package securitygroups
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type SecurityGroupsArgs struct {
+	VpcID                     pulumi.IDOutput
+	AppSecurityGroupName      string
+	DatabaseSecurityGroupName string
+	RuleProtocol              string
+	PublicDestinationCidr     string
+	InboundPorts              map[string]int
+	DatabasePort              int
+	DatabaseProtocol          string
+	// HTTPRedirect opens the http port on the load balancer, which only redirects to https
+	HTTPRedirect bool
+	// TargetPorts are the ports of the additional load balancer target groups served by the app
+	TargetPorts []int
+	// RDSProxy adds the security group of the RDS proxy between the app and the database, the proxy reaches the
+	// database port inside VpcCidr
+	RDSProxy               bool
+	ProxySecurityGroupName string
+	VpcCidr                string
+	// ProxyPreviousParent is the URN of the database component, which created the proxy security group before
+	ProxyPreviousParent pulumi.URNInput
+}
+
+// SecurityGroups chains the load balancer, app and database security groups so that each tier only accepts
+// traffic from the tier in front of it.
+type SecurityGroups struct {
+	pulumi.ResourceState
+
+	LoadBalancerSecurityGroupID pulumi.IDOutput
+	AppSecurityGroupID          pulumi.IDOutput
+	DatabaseSecurityGroupID     pulumi.IDOutput
+	// ProxySecurityGroupID is only set when the RDS proxy is enabled
+	ProxySecurityGroupID pulumi.IDOutput
+}
+
+func NewSecurityGroups(ctx *pulumi.Context, name string, args *SecurityGroupsArgs, opts ...pulumi.ResourceOption) (*SecurityGroups, error) {
+	securityGroups := &SecurityGroups{}
+	err := ctx.RegisterComponentResource("webapp:securitygroups:SecurityGroups", name, securityGroups, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources were created at the top level of the stack before they were grouped, keep their URNs
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(securityGroups), pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}})}
+
+	loadBalancerIngress := ec2.SecurityGroupIngressArray{
+		&ec2.SecurityGroupIngressArgs{
+			Description: pulumi.String("Allow inbound HTTPS traffic on port 443 from all public IP addresses"),
+			FromPort:    pulumi.Int(args.InboundPorts["https"]),
+			ToPort:      pulumi.Int(args.InboundPorts["https"]),
+			Protocol:    pulumi.String(args.RuleProtocol),
+			CidrBlocks:  pulumi.StringArray{pulumi.String(args.PublicDestinationCidr)},
+		},
+	}
+
+	if args.HTTPRedirect {
+		httpPort, ok := args.InboundPorts["http"]
+		if !ok {
+			httpPort = 80
+		}
+
+		loadBalancerIngress = append(loadBalancerIngress, &ec2.SecurityGroupIngressArgs{
+			Description: pulumi.String("Allow inbound HTTP traffic from all public IP addresses, redirected to HTTPS"),
+			FromPort:    pulumi.Int(httpPort),
+			ToPort:      pulumi.Int(httpPort),
+			Protocol:    pulumi.String(args.RuleProtocol),
+			CidrBlocks:  pulumi.StringArray{pulumi.String(args.PublicDestinationCidr)},
+		})
+	}
+
+	loadBalancerEgress := ec2.SecurityGroupEgressArray{
+		&ec2.SecurityGroupEgressArgs{
+			FromPort:   pulumi.Int(args.InboundPorts["customPort"]),
+			ToPort:     pulumi.Int(args.InboundPorts["customPort"]),
+			Protocol:   pulumi.String(args.RuleProtocol),
+			CidrBlocks: pulumi.StringArray{pulumi.String(args.PublicDestinationCidr)},
+		},
+	}
+
+	for _, port := range args.TargetPorts {
+		loadBalancerEgress = append(loadBalancerEgress, &ec2.SecurityGroupEgressArgs{
+			FromPort:   pulumi.Int(port),
+			ToPort:     pulumi.Int(port),
+			Protocol:   pulumi.String(args.RuleProtocol),
+			CidrBlocks: pulumi.StringArray{pulumi.String(args.PublicDestinationCidr)},
+		})
+	}
+
+	// Create a new security group for load balancer
+	loadBalancerSecurityGroup, err := ec2.NewSecurityGroup(ctx, "load-balancer-security-group", &ec2.SecurityGroupArgs{
+		VpcId: args.VpcID,
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String("load-balancer-security-group"),
+		},
+		Ingress: loadBalancerIngress,
+		Egress:  loadBalancerEgress,
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	appIngress := ec2.SecurityGroupIngressArray{
+		&ec2.SecurityGroupIngressArgs{
+			Description: pulumi.String("Allow inbound HTTPS traffic on port 8080 from public all public IP addresses"),
+			FromPort:    pulumi.Int(args.InboundPorts["customPort"]),
+			ToPort:      pulumi.Int(args.InboundPorts["customPort"]),
+			Protocol:    pulumi.String(args.RuleProtocol),
+			SecurityGroups: pulumi.StringArray{
+				loadBalancerSecurityGroup.ID(),
+			},
+		},
+		&ec2.SecurityGroupIngressArgs{
+			Description: pulumi.String("Allow inbound SSH traffic on port 22 from custom IP"),
+			FromPort:    pulumi.Int(args.InboundPorts["ssh"]),
+			ToPort:      pulumi.Int(args.InboundPorts["ssh"]),
+			Protocol:    pulumi.String(args.RuleProtocol),
+			SecurityGroups: pulumi.StringArray{
+				loadBalancerSecurityGroup.ID(),
+			},
+		},
+	}
+
+	for _, port := range args.TargetPorts {
+		appIngress = append(appIngress, &ec2.SecurityGroupIngressArgs{
+			Description: pulumi.String(fmt.Sprintf("Allow inbound traffic on port %d from the load balancer", port)),
+			FromPort:    pulumi.Int(port),
+			ToPort:      pulumi.Int(port),
+			Protocol:    pulumi.String(args.RuleProtocol),
+			SecurityGroups: pulumi.StringArray{
+				loadBalancerSecurityGroup.ID(),
+			},
+		})
+	}
+
+	// Create a new security group for application running in EC2
+	appSecurityGroup, err := ec2.NewSecurityGroup(ctx, args.AppSecurityGroupName, &ec2.SecurityGroupArgs{
+		VpcId: args.VpcID,
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(args.AppSecurityGroupName),
+		},
+		Ingress: appIngress,
+		Egress: ec2.SecurityGroupEgressArray{
+			&ec2.SecurityGroupEgressArgs{
+				FromPort:   pulumi.Int(0),
+				ToPort:     pulumi.Int(0),
+				Protocol:   pulumi.String("-1"),
+				CidrBlocks: pulumi.StringArray{pulumi.String(args.PublicDestinationCidr)},
+			},
+		},
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	databaseIngress := ec2.SecurityGroupIngressArray{
+		&ec2.SecurityGroupIngressArgs{
+			Description: pulumi.String("Allow traffic from resources that use appSecurityGroup through 5432 port"),
+			FromPort:    pulumi.Int(args.DatabasePort),
+			ToPort:      pulumi.Int(args.DatabasePort),
+			Protocol:    pulumi.String(args.DatabaseProtocol),
+			SecurityGroups: pulumi.StringArray{
+				appSecurityGroup.ID(),
+			},
+		},
+	}
+
+	// The proxy egress can't reference the database security group, which references the proxy security group, so
+	// it is limited to the database port inside the VPC
+	if args.RDSProxy {
+		proxySecurityGroupName := args.ProxySecurityGroupName
+		if proxySecurityGroupName == "" {
+			proxySecurityGroupName = "rds-proxy-security-group"
+		}
+
+		// The proxy security group was created by the database component before it moved here, keep its URN
+		proxyOpts := []pulumi.ResourceOption{pulumi.Parent(securityGroups)}
+		if args.ProxyPreviousParent != nil {
+			proxyOpts = append(proxyOpts, pulumi.Aliases([]pulumi.Alias{{ParentURN: args.ProxyPreviousParent}}))
+		}
+
+		proxySecurityGroup, err := ec2.NewSecurityGroup(ctx, proxySecurityGroupName, &ec2.SecurityGroupArgs{
+			VpcId:       args.VpcID,
+			Description: pulumi.String("Custom RDS Proxy Security Group"),
+			Tags: pulumi.StringMap{
+				"Name": pulumi.String(proxySecurityGroupName),
+			},
+			Ingress: ec2.SecurityGroupIngressArray{
+				&ec2.SecurityGroupIngressArgs{
+					Description:    pulumi.String("Allow traffic from resources that use appSecurityGroup through the database port"),
+					FromPort:       pulumi.Int(args.DatabasePort),
+					ToPort:         pulumi.Int(args.DatabasePort),
+					Protocol:       pulumi.String(args.DatabaseProtocol),
+					SecurityGroups: pulumi.StringArray{appSecurityGroup.ID()},
+				},
+			},
+			Egress: ec2.SecurityGroupEgressArray{
+				&ec2.SecurityGroupEgressArgs{
+					Description: pulumi.String("Allow traffic to the database port inside the VPC"),
+					FromPort:    pulumi.Int(args.DatabasePort),
+					ToPort:      pulumi.Int(args.DatabasePort),
+					Protocol:    pulumi.String(args.DatabaseProtocol),
+					CidrBlocks:  pulumi.StringArray{pulumi.String(args.VpcCidr)},
+				},
+			},
+		}, proxyOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		databaseIngress = append(databaseIngress, &ec2.SecurityGroupIngressArgs{
+			Description:    pulumi.String("Allow traffic from the RDS proxy through the database port"),
+			FromPort:       pulumi.Int(args.DatabasePort),
+			ToPort:         pulumi.Int(args.DatabasePort),
+			Protocol:       pulumi.String(args.DatabaseProtocol),
+			SecurityGroups: pulumi.StringArray{proxySecurityGroup.ID()},
+		})
+		securityGroups.ProxySecurityGroupID = proxySecurityGroup.ID()
+	}
+
+	// Create a custom security group for the RDS instance.
+	databaseSecurityGroup, err := ec2.NewSecurityGroup(ctx, args.DatabaseSecurityGroupName, &ec2.SecurityGroupArgs{
+		VpcId:       args.VpcID,
+		Description: pulumi.String("Custom RDS Security Group"),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String("database-security-group"),
+		},
+		Ingress: databaseIngress,
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ec2.NewSecurityGroupRule(ctx, "AllowOutboundToDB", &ec2.SecurityGroupRuleArgs{
+		Type:                  pulumi.String("egress"),
+		FromPort:              pulumi.Int(args.DatabasePort),
+		ToPort:                pulumi.Int(args.DatabasePort),
+		Protocol:              pulumi.String(args.DatabaseProtocol),
+		SourceSecurityGroupId: databaseSecurityGroup.ID(),
+		SecurityGroupId:       appSecurityGroup.ID(),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ec2.NewSecurityGroupRule(ctx, "AllowOutboundToInternet", &ec2.SecurityGroupRuleArgs{
+		Type:            pulumi.String("egress"),
+		FromPort:        pulumi.Int(443),
+		ToPort:          pulumi.Int(443),
+		Protocol:        pulumi.String(args.DatabaseProtocol),
+		CidrBlocks:      pulumi.StringArray{pulumi.String(args.PublicDestinationCidr)},
+		SecurityGroupId: appSecurityGroup.ID(),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	securityGroups.LoadBalancerSecurityGroupID = loadBalancerSecurityGroup.ID()
+	securityGroups.AppSecurityGroupID = appSecurityGroup.ID()
+	securityGroups.DatabaseSecurityGroupID = databaseSecurityGroup.ID()
+
+	outputs := pulumi.Map{
+		"loadBalancerSecurityGroupId": securityGroups.LoadBalancerSecurityGroupID,
+		"appSecurityGroupId":          securityGroups.AppSecurityGroupID,
+		"databaseSecurityGroupId":     securityGroups.DatabaseSecurityGroupID,
+	}
+	if args.RDSProxy {
+		outputs["proxySecurityGroupId"] = securityGroups.ProxySecurityGroupID
+	}
+
+	err = ctx.RegisterResourceOutputs(securityGroups, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return securityGroups, nil
+}
@@ -0,0 +1,167 @@
+package tagging
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/autoscaling"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Tags are the mandatory tags from the config, the stack and project tags are taken from the running stack.
+type Tags struct {
+	Environment string            `json:"environment,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+	CostCenter  string            `json:"cost_center,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+}
+
+var (
+	stringMapInputType = reflect.TypeOf((*pulumi.StringMapInput)(nil)).Elem()
+	groupTagArrayType  = reflect.TypeOf((*autoscaling.GroupTagArrayInput)(nil)).Elem()
+)
+
+// MandatoryTags validates the configured tags and returns them together with the stack and project tags.
+func MandatoryTags(ctx *pulumi.Context, tags Tags) (map[string]string, error) {
+	mandatory := map[string]string{
+		"environment": tags.Environment,
+		"owner":       tags.Owner,
+		"cost-center": tags.CostCenter,
+	}
+
+	for _, key := range []string{"environment", "owner", "cost-center"} {
+		if mandatory[key] == "" {
+			return nil, fmt.Errorf(`{"status": 400, "msg": "Missing mandatory tag %s in tags config."}`, key)
+		}
+	}
+
+	// Extra tags can't override the mandatory ones
+	for key, value := range tags.Extra {
+		if _, ok := mandatory[key]; !ok {
+			mandatory[key] = value
+		}
+	}
+
+	mandatory["stack"] = ctx.Stack()
+	mandatory["project"] = ctx.Project()
+
+	return mandatory, nil
+}
+
+// NameTag is the Name tag of resources that don't set one explicitly.
+func NameTag(ctx *pulumi.Context, resourceName string) string {
+	return fmt.Sprintf("%s-%s-%s", ctx.Project(), ctx.Stack(), resourceName)
+}
+
+// ApplyMandatoryTags registers a stack transformation adding the mandatory tags and a Name tag to every taggable
+// resource. A transformation is used over the provider default tags so that the auto scaling group tags, which are
+// not a map, are covered as well and propagate to the launched instances.
+func ApplyMandatoryTags(ctx *pulumi.Context, tags Tags) error {
+	mandatory, err := MandatoryTags(ctx, tags)
+	if err != nil {
+		return err
+	}
+
+	return ctx.RegisterStackTransformation(func(args *pulumi.ResourceTransformationArgs) *pulumi.ResourceTransformationResult {
+		props := reflect.ValueOf(args.Props)
+		if props.Kind() != reflect.Ptr || props.IsNil() || props.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+
+		field := props.Elem().FieldByName("Tags")
+		if !field.IsValid() || !field.CanSet() {
+			return nil
+		}
+
+		defaults := map[string]string{"Name": NameTag(ctx, args.Name)}
+		for key, value := range mandatory {
+			defaults[key] = value
+		}
+
+		switch field.Type() {
+		case stringMapInputType:
+			existing, _ := field.Interface().(pulumi.StringMapInput)
+			field.Set(reflect.ValueOf(mergeTags(existing, defaults)))
+		case groupTagArrayType:
+			existing, _ := field.Interface().(autoscaling.GroupTagArrayInput)
+			field.Set(reflect.ValueOf(mergeGroupTags(existing, defaults)))
+		default:
+			return nil
+		}
+
+		return &pulumi.ResourceTransformationResult{Props: args.Props, Opts: args.Opts}
+	})
+}
+
+// mergeTags adds the defaults to the tags of a resource, tags set on the resource win.
+func mergeTags(existing pulumi.StringMapInput, defaults map[string]string) pulumi.StringMapInput {
+	tags := pulumi.StringMap{}
+	for key, value := range defaults {
+		tags[key] = pulumi.String(value)
+	}
+
+	switch existing := existing.(type) {
+	case nil:
+		return tags
+	case pulumi.StringMap:
+		for key, value := range existing {
+			tags[key] = value
+		}
+
+		return tags
+	default:
+		return existing.ToStringMapOutput().ApplyT(func(existing map[string]string) map[string]string {
+			merged := make(map[string]string, len(defaults)+len(existing))
+			for key, value := range defaults {
+				merged[key] = value
+			}
+
+			for key, value := range existing {
+				merged[key] = value
+			}
+
+			return merged
+		}).(pulumi.StringMapOutput)
+	}
+}
+
+// mergeGroupTags adds the defaults to the tags of an auto scaling group, propagated to the instances it launches.
+func mergeGroupTags(existing autoscaling.GroupTagArrayInput, defaults map[string]string) autoscaling.GroupTagArrayInput {
+	tags, ok := existing.(autoscaling.GroupTagArray)
+	if existing != nil && !ok {
+		// Tags that are only known after other resources are created are left as they are
+		return existing
+	}
+
+	set := map[string]bool{}
+	for _, tag := range tags {
+		if args, ok := tag.(*autoscaling.GroupTagArgs); ok {
+			if key, ok := args.Key.(pulumi.String); ok {
+				set[string(key)] = true
+			}
+		}
+	}
+
+	// Sorted so that the order of the tags doesn't change between runs
+	keys := make([]string, 0, len(defaults))
+	for key := range defaults {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	merged := append(autoscaling.GroupTagArray{}, tags...)
+	for _, key := range keys {
+		if set[key] {
+			continue
+		}
+
+		merged = append(merged, &autoscaling.GroupTagArgs{
+			Key:               pulumi.String(key),
+			Value:             pulumi.String(defaults[key]),
+			PropagateAtLaunch: pulumi.Bool(true),
+		})
+	}
+
+	return merged
+}
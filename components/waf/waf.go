@@ -0,0 +1,281 @@
+package waf
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/wafv2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/encryption"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+)
+
+type WAF struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// ManagedRuleGroups are evaluated in order after the IP sets and the rate limit, DefaultManagedRuleGroups when not set
+	ManagedRuleGroups []ManagedRuleGroup `json:"managed_rule_groups,omitempty"`
+	// RateLimit is the number of requests an IP can make in 5 minutes before it is blocked, 0 disables it
+	RateLimit  int      `json:"rate_limit,omitempty"`
+	AllowedIPs []string `json:"allowed_ips,omitempty"`
+	BlockedIPs []string `json:"blocked_ips,omitempty"`
+	// LogRetentionInDays is how long the WAF logs are kept, 30 when not set
+	LogRetentionInDays int `json:"log_retention_in_days,omitempty"`
+}
+
+type ManagedRuleGroup struct {
+	Name       string `json:"name"`
+	VendorName string `json:"vendor_name,omitempty"`
+	// Count only counts the requests the group matches, to try a group out before it blocks
+	Count bool `json:"count,omitempty"`
+	// CountRules are the rules of the group that only count, e.g. SizeRestrictions_BODY for uploads
+	CountRules []string `json:"count_rules,omitempty"`
+}
+
+// DefaultManagedRuleGroups are the AWS managed baseline rule groups for a public web app.
+var DefaultManagedRuleGroups = []ManagedRuleGroup{
+	{Name: "AWSManagedRulesAmazonIpReputationList"},
+	{Name: "AWSManagedRulesCommonRuleSet"},
+	{Name: "AWSManagedRulesKnownBadInputsRuleSet"},
+}
+
+type WebACLArgs struct {
+	Config WAF
+	// LoadBalancerArn is the load balancer the web ACL protects
+	LoadBalancerArn pulumi.StringInput
+	LogsKmsKey      *kms.Key
+	Namer           *naming.Namer
+}
+
+// WebACL is the regional WAF web ACL in front of the load balancer, logging to CloudWatch.
+type WebACL struct {
+	pulumi.ResourceState
+
+	Arn pulumi.StringOutput
+}
+
+// ValidateWAF checks the config against the limits of WAF before anything is created.
+func ValidateWAF(config WAF) error {
+	if config.RateLimit != 0 && (config.RateLimit < 100 || config.RateLimit > 2000000000) {
+		return fmt.Errorf(`{"status": 400, "msg": "Incorrect param rate_limit %d, it must be 0 or between 100 and 2000000000."}`, config.RateLimit)
+	}
+
+	for _, cidr := range append(append([]string{}, config.AllowedIPs...), config.BlockedIPs...) {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf(`{"status": 400, "msg": "Incorrect CIDR %s in the WAF IP sets."}`, cidr)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, group := range config.ManagedRuleGroups {
+		if group.Name == "" || names[group.Name] {
+			return fmt.Errorf(`{"status": 400, "msg": "Managed rule group names must be unique and not empty, got %q."}`, group.Name)
+		}
+
+		names[group.Name] = true
+	}
+
+	return nil
+}
+
+// splitByVersion splits CIDRs into IPv4 and IPv6, a WAF IP set only holds addresses of one version.
+func splitByVersion(cidrs []string) map[string][]string {
+	versions := map[string][]string{}
+	for _, cidr := range cidrs {
+		ip, _, _ := net.ParseCIDR(cidr)
+		if ip.To4() != nil {
+			versions["IPV4"] = append(versions["IPV4"], cidr)
+		} else {
+			versions["IPV6"] = append(versions["IPV6"], cidr)
+		}
+	}
+
+	return versions
+}
+
+func visibilityConfig(metricName string) *wafv2.WebAclRuleVisibilityConfigArgs {
+	return &wafv2.WebAclRuleVisibilityConfigArgs{
+		CloudwatchMetricsEnabled: pulumi.Bool(true),
+		MetricName:               pulumi.String(metricName),
+		SampledRequestsEnabled:   pulumi.Bool(true),
+	}
+}
+
+func NewWebACL(ctx *pulumi.Context, name string, args *WebACLArgs, opts ...pulumi.ResourceOption) (*WebACL, error) {
+	config := args.Config
+	if err := ValidateWAF(config); err != nil {
+		return nil, err
+	}
+
+	if len(config.ManagedRuleGroups) == 0 {
+		config.ManagedRuleGroups = DefaultManagedRuleGroups
+	}
+
+	if config.LogRetentionInDays == 0 {
+		config.LogRetentionInDays = 30
+	}
+
+	webACL := &WebACL{}
+	err := ctx.RegisterComponentResource("webapp:waf:WebACL", name, webACL, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	childOpts := []pulumi.ResourceOption{pulumi.Parent(webACL)}
+
+	// Allowed IPs skip the rest of the rules, blocked IPs are blocked before the rate limit counts them
+	var rules wafv2.WebAclRuleArray
+	for _, list := range []struct {
+		name   string
+		cidrs  []string
+		action *wafv2.WebAclRuleActionArgs
+	}{
+		{name: "allowed-ips", cidrs: config.AllowedIPs, action: &wafv2.WebAclRuleActionArgs{Allow: &wafv2.WebAclRuleActionAllowArgs{}}},
+		{name: "blocked-ips", cidrs: config.BlockedIPs, action: &wafv2.WebAclRuleActionArgs{Block: &wafv2.WebAclRuleActionBlockArgs{}}},
+	} {
+		versions := splitByVersion(list.cidrs)
+		for _, version := range []string{"IPV4", "IPV6"} {
+			if len(versions[version]) == 0 {
+				continue
+			}
+
+			setName := fmt.Sprintf("%s-%s", list.name, strings.ToLower(version))
+			ipSet, err := wafv2.NewIpSet(ctx, setName, &wafv2.IpSetArgs{
+				Name:             pulumi.String(args.Namer.Name(naming.WAFIPSet, setName)),
+				Scope:            pulumi.String("REGIONAL"),
+				IpAddressVersion: pulumi.String(version),
+				Addresses:        pulumi.ToStringArray(versions[version]),
+			}, childOpts...)
+			if err != nil {
+				return nil, err
+			}
+
+			rules = append(rules, &wafv2.WebAclRuleArgs{
+				Name:     pulumi.String(setName),
+				Priority: pulumi.Int(len(rules)),
+				Action:   list.action,
+				Statement: &wafv2.WebAclRuleStatementArgs{
+					IpSetReferenceStatement: &wafv2.WebAclRuleStatementIpSetReferenceStatementArgs{
+						Arn: ipSet.Arn,
+					},
+				},
+				VisibilityConfig: visibilityConfig(setName),
+			})
+		}
+	}
+
+	if config.RateLimit != 0 {
+		rules = append(rules, &wafv2.WebAclRuleArgs{
+			Name:     pulumi.String("rate-limit"),
+			Priority: pulumi.Int(len(rules)),
+			Action:   &wafv2.WebAclRuleActionArgs{Block: &wafv2.WebAclRuleActionBlockArgs{}},
+			Statement: &wafv2.WebAclRuleStatementArgs{
+				RateBasedStatement: &wafv2.WebAclRuleStatementRateBasedStatementArgs{
+					Limit:            pulumi.Int(config.RateLimit),
+					AggregateKeyType: pulumi.String("IP"),
+				},
+			},
+			VisibilityConfig: visibilityConfig("rate-limit"),
+		})
+	}
+
+	for _, group := range config.ManagedRuleGroups {
+		vendorName := group.VendorName
+		if vendorName == "" {
+			vendorName = "AWS"
+		}
+
+		overrideAction := &wafv2.WebAclRuleOverrideActionArgs{None: &wafv2.WebAclRuleOverrideActionNoneArgs{}}
+		if group.Count {
+			overrideAction = &wafv2.WebAclRuleOverrideActionArgs{Count: &wafv2.WebAclRuleOverrideActionCountArgs{}}
+		}
+
+		var ruleActionOverrides wafv2.WebAclRuleStatementManagedRuleGroupStatementRuleActionOverrideArray
+		for _, rule := range group.CountRules {
+			ruleActionOverrides = append(ruleActionOverrides, &wafv2.WebAclRuleStatementManagedRuleGroupStatementRuleActionOverrideArgs{
+				Name: pulumi.String(rule),
+				ActionToUse: &wafv2.WebAclRuleStatementManagedRuleGroupStatementRuleActionOverrideActionToUseArgs{
+					Count: &wafv2.WebAclRuleStatementManagedRuleGroupStatementRuleActionOverrideActionToUseCountArgs{},
+				},
+			})
+		}
+
+		rules = append(rules, &wafv2.WebAclRuleArgs{
+			Name:           pulumi.String(group.Name),
+			Priority:       pulumi.Int(len(rules)),
+			OverrideAction: overrideAction,
+			Statement: &wafv2.WebAclRuleStatementArgs{
+				ManagedRuleGroupStatement: &wafv2.WebAclRuleStatementManagedRuleGroupStatementArgs{
+					Name:                pulumi.String(group.Name),
+					VendorName:          pulumi.String(vendorName),
+					RuleActionOverrides: ruleActionOverrides,
+				},
+			},
+			VisibilityConfig: visibilityConfig(group.Name),
+		})
+	}
+
+	webACLName := args.Namer.Name(naming.WAFWebACL, "app")
+	acl, err := wafv2.NewWebAcl(ctx, "app-web-acl", &wafv2.WebAclArgs{
+		Name:          pulumi.String(webACLName),
+		Scope:         pulumi.String("REGIONAL"),
+		DefaultAction: &wafv2.WebAclDefaultActionArgs{Allow: &wafv2.WebAclDefaultActionAllowArgs{}},
+		Rules:         rules,
+		VisibilityConfig: &wafv2.WebAclVisibilityConfigArgs{
+			CloudwatchMetricsEnabled: pulumi.Bool(true),
+			MetricName:               pulumi.String(webACLName),
+			SampledRequestsEnabled:   pulumi.Bool(true),
+		},
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// WAF only logs to log groups whose name starts with aws-waf-logs-
+	logGroupName := args.Namer.Name(naming.WAFLogGroup, "app")
+	logGroup, err := cloudwatch.NewLogGroup(ctx, "app-web-acl-logs", &cloudwatch.LogGroupArgs{
+		Name:            pulumi.String(logGroupName),
+		RetentionInDays: pulumi.Int(config.LogRetentionInDays),
+		KmsKeyId:        encryption.KMSKeyArn(args.LogsKmsKey),
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Session tokens and API keys in the Authorization header are kept out of the logs
+	_, err = wafv2.NewWebAclLoggingConfiguration(ctx, "app-web-acl-logging", &wafv2.WebAclLoggingConfigurationArgs{
+		ResourceArn:           acl.Arn,
+		LogDestinationConfigs: pulumi.StringArray{logGroup.Arn},
+		RedactedFields: wafv2.WebAclLoggingConfigurationRedactedFieldArray{
+			&wafv2.WebAclLoggingConfigurationRedactedFieldArgs{
+				SingleHeader: &wafv2.WebAclLoggingConfigurationRedactedFieldSingleHeaderArgs{
+					Name: pulumi.String("authorization"),
+				},
+			},
+		},
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = wafv2.NewWebAclAssociation(ctx, "app-web-acl-association", &wafv2.WebAclAssociationArgs{
+		ResourceArn: args.LoadBalancerArn,
+		WebAclArn:   acl.Arn,
+	}, childOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	webACL.Arn = acl.Arn
+
+	err = ctx.RegisterResourceOutputs(webACL, pulumi.Map{
+		"arn": webACL.Arn,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return webACL, nil
+}
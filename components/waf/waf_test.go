@@ -0,0 +1,60 @@
+package waf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateWAF(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  WAF
+		wantErr string
+	}{
+		{
+			name:   "all settings",
+			config: WAF{RateLimit: 2000, AllowedIPs: []string{"10.0.0.0/8"}, BlockedIPs: []string{"2001:db8::/32"}, ManagedRuleGroups: DefaultManagedRuleGroups},
+		},
+		{
+			name:    "rate limit under the WAF minimum",
+			config:  WAF{RateLimit: 50},
+			wantErr: "rate_limit",
+		},
+		{
+			name:    "address without a prefix length",
+			config:  WAF{BlockedIPs: []string{"203.0.113.7"}},
+			wantErr: "Incorrect CIDR",
+		},
+		{
+			name:    "duplicate managed rule group",
+			config:  WAF{ManagedRuleGroups: []ManagedRuleGroup{{Name: "AWSManagedRulesCommonRuleSet"}, {Name: "AWSManagedRulesCommonRuleSet"}}},
+			wantErr: "must be unique",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWAF(tt.config)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ValidateWAF() = %v, want no error", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ValidateWAF() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitByVersion(t *testing.T) {
+	got := splitByVersion([]string{"10.0.0.0/8", "2001:db8::/32", "203.0.113.7/32"})
+	want := map[string][]string{
+		"IPV4": {"10.0.0.0/8", "203.0.113.7/32"},
+		"IPV6": {"2001:db8::/32"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitByVersion() = %v, want %v", got, want)
+	}
+}
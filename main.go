@@ -2,44 +2,27 @@ package main
 
 import (
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/cloudwatch"
-	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/sns"
-	"os"
-	"strconv"
+	"slices"
 	"strings"
 
-	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
-	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/autoscaling"
-	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
-	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
-	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lb"
-	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/rds"
-	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/route53"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/apptier"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/database"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/deployment"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/dns"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/encryption"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/loadbalancer"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/messaging"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/naming"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/network"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/securitygroups"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/tagging"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/waf"
 )
 
-type EC2Instance struct {
-	InstanceName             string `json:"instance_name,omitempty"`
-	InstanceType             string `json:"instance_type,omitempty"`
-	VolumeSize               int    `json:"volume_size,omitempty"`
-	VolumeType               string `json:"volume_type,omitempty"`
-	DeleteOnTermination      bool   `json:"delete_on_termination,omitempty"`
-	DisableApiTermination    bool   `json:"disable_api_termination,omitempty"`
-	AssociatePublicIpAddress bool   `json:"associate_public_ip,omitempty"`
-	DeviceType               string `json:"device_type,omitempty"`
-	AmiID                    string `json:"ami_id,omitempty"`
-	SSHKeyName               string `json:"ssh_key_name,omitempty"`
-	LogFilePath              string `json:"log_file_path,omitempty"`
-	MetricServerPort         int    `json:"metric_server_port,omitempty"`
-	UserDataFilePath         string `json:"users_data_file_path,omitempty"`
-	MigrationsFilePath       string `json:"migrations_file_path,omitempty"`
-	PublicKeyFilePath        string `json:"public_key_file_path,omitempty"`
-}
-
 type Resource struct {
 	Region         string `json:"region"`
 	AccountID      string `json:"account_id"`
@@ -56,66 +39,58 @@ type MailerClient struct {
 }
 
 type DNS struct {
-	ARecordName  string `json:"a_record_name,omitempty"`
-	Type         string `json:"type,omitempty"`
-	Ttl          int    `json:"ttl,omitempty"`
-	Domain       string `json:"domain,omitempty"`
-	HostedZoneID string `json:"hosted_zone_id,omitempty"`
-}
-
-type RDSInstance struct {
-	SubnetGrp          string `json:"private_subnet_group,omitempty"`
-	SecurityGroupName  string `json:"security_group_name,omitempty"`
-	AllowsPort         int    `json:"allows_port,omitempty"`
-	Protocol           string `json:"protocol,omitempty"`
-	InstanceName       string `json:"instance_name,omitempty"`
-	Engine             string `json:"engine,omitempty"`
-	EngineVersion      string `json:"engine_version,omitempty"`
-	InstanceClass      string `json:"instance_class,omitempty"`
-	AllowedStorage     int    `json:"allowed_storage,omitempty"`
-	Identifier         string `json:"identifier,omitempty"`
-	Username           string `json:"username,omitempty"`
-	Password           string `json:"password,omitempty"`
-	DbName             string `json:"db_name,omitempty"`
-	DbDriver           string `json:"db_driver,omitempty"`
-	PubliclyAccessible bool   `json:"publicly_accessible,omitempty"`
-	MultiAz            bool   `json:"multi_az,omitempty"`
-	SkipFinalSnapShot  bool   `json:"skip_final_snapshot,omitempty"`
-	StorageEncrypted   bool   `json:"storage_encrypted,omitempty"`
+	ARecordName  string          `json:"a_record_name,omitempty"`
+	Type         string          `json:"type,omitempty"`
+	Ttl          int             `json:"ttl,omitempty"`
+	Domain       string          `json:"domain,omitempty"`
+	HostedZoneID string          `json:"hosted_zone_id,omitempty"`
+	Certificate  dns.Certificate `json:"certificate,omitempty"`
 }
 
 type Data struct {
-	Vpc                                       string            `json:"vpc,omitempty"`
-	VpcCidar                                  string            `json:"vpc_cidar,omitempty"`
-	VpcInstanceTenancy                        string            `json:"vpc_instance_tenancy"`
-	InternetGateway                           string            `json:"internet_gateway,omitempty"`
-	InternetGatewayAttachment                 string            `json:"internet_gateway_attachment,omitempty"`
-	PublicRoute                               string            `json:"public_route,omitempty"`
-	PublicRouteTable                          string            `json:"public_route_table,omitempty"`
-	PrivateRouteTable                         string            `json:"private_route_table,omitempty"`
-	PublicDestinationCidar                    string            `json:"public_destination_cidar,omitempty"`
-	PublicSubnets                             []string          `json:"public_subnets,omitempty"`
-	PrivateSubnets                            []string          `json:"private_subnets,omitempty"`
-	BitsToMask                                int               `json:"bits_to_mask,omitempty"`
-	MaxAvailabilityZones                      int               `json:"max_availability_zones,omitempty"`
-	AvailabilityZones                         []string          `json:"availability_zones,omitempty"`
-	PublicSubnetsPrefix                       string            `json:"public_subnets_prefix,omitempty"`
-	PrivateSubnetPrefix                       string            `json:"private_subnets_prefix,omitempty"`
-	SecurityGroup                             string            `json:"security_group,omitempty"`
-	SecurityRuleType                          string            `json:"security_rule_type,omitempty"`
-	SecurityRuleProtocol                      string            `json:"security_rule_protocol,omitempty"`
-	SecurityRuleNames                         map[string]string `json:"security_rule_names,omitempty"`
-	InboundPorts                              map[string]int    `json:"all_inbound_ports,omitempty"`
-	FetchPublicIPURL                          string            `json:"url_to_fetch_public_ip,omitempty"`
-	EC2InstanceMetadata                       EC2Instance       `json:"ec2_instance_metadata,omitempty"`
-	Dns                                       DNS               `json:"dns,omitempty"`
-	ResourceParams                            Resource          `json:"resource_params"`
-	RDSInstanceMetadata                       RDSInstance       `json:"rds_instance_metadata,omitempty"`
-	MailerClientCreds                         MailerClient      `json:"mailer_client_crds,omitempty"`
-	PublicRouteTableSubnetsAssociationPrefix  string            `json:"public_route_table_subnets_association_prefix,omitempty"`
-	PrivateRouteTableSubnetsAssociationPrefix string            `json:"private_route_table_subnets_association_prefix,omitempty"`
+	Vpc                                       string               `json:"vpc,omitempty"`
+	VpcCidar                                  string               `json:"vpc_cidar,omitempty"`
+	VpcInstanceTenancy                        string               `json:"vpc_instance_tenancy"`
+	InternetGateway                           string               `json:"internet_gateway,omitempty"`
+	InternetGatewayAttachment                 string               `json:"internet_gateway_attachment,omitempty"`
+	PublicRoute                               string               `json:"public_route,omitempty"`
+	PublicRouteTable                          string               `json:"public_route_table,omitempty"`
+	PrivateRouteTable                         string               `json:"private_route_table,omitempty"`
+	PublicDestinationCidar                    string               `json:"public_destination_cidar,omitempty"`
+	PublicSubnets                             []string             `json:"public_subnets,omitempty"`
+	PrivateSubnets                            []string             `json:"private_subnets,omitempty"`
+	BitsToMask                                int                  `json:"bits_to_mask,omitempty"`
+	MaxAvailabilityZones                      int                  `json:"max_availability_zones,omitempty"`
+	AvailabilityZones                         []string             `json:"availability_zones,omitempty"`
+	PublicSubnetsPrefix                       string               `json:"public_subnets_prefix,omitempty"`
+	PrivateSubnetPrefix                       string               `json:"private_subnets_prefix,omitempty"`
+	SecurityGroup                             string               `json:"security_group,omitempty"`
+	SecurityRuleType                          string               `json:"security_rule_type,omitempty"`
+	SecurityRuleProtocol                      string               `json:"security_rule_protocol,omitempty"`
+	SecurityRuleNames                         map[string]string    `json:"security_rule_names,omitempty"`
+	InboundPorts                              map[string]int       `json:"all_inbound_ports,omitempty"`
+	FetchPublicIPURL                          string               `json:"url_to_fetch_public_ip,omitempty"`
+	EC2InstanceMetadata                       apptier.EC2Instance  `json:"ec2_instance_metadata,omitempty"`
+	Dns                                       DNS                  `json:"dns,omitempty"`
+	ResourceParams                            Resource             `json:"resource_params"`
+	RDSInstanceMetadata                       database.RDSInstance `json:"rds_instance_metadata,omitempty"`
+	MailerClientCreds                         MailerClient         `json:"mailer_client_crds,omitempty"`
+	Kms                                       encryption.KMS       `json:"kms,omitempty"`
+	LogGroups                                 []apptier.LogGroup   `json:"log_groups,omitempty"`
+	AutoScaling                               apptier.AutoScaling  `json:"autoscaling,omitempty"`
+	Tags                                      tagging.Tags         `json:"tags,omitempty"`
+	Naming                                    naming.Config        `json:"naming,omitempty"`
+	LoadBalancer                              loadbalancer.ALB     `json:"load_balancer,omitempty"`
+	WAF                                       waf.WAF              `json:"waf,omitempty"`
+	BlueGreen                                 deployment.BlueGreen `json:"blue_green,omitempty"`
+	PublicRouteTableSubnetsAssociationPrefix  string               `json:"public_route_table_subnets_association_prefix,omitempty"`
+	PrivateRouteTableSubnetsAssociationPrefix string               `json:"private_route_table_subnets_association_prefix,omitempty"`
 }
 
+// databaseComponentName is the name of the database component, the URN of the proxy security group alias is built
+// from it
+const databaseComponentName = "webapp-database"
+
 func main() {
 	pulumi.Run(func(ctx *pulumi.Context) error {
 		// Load configuration values from pulumi.*.yaml file
@@ -123,536 +98,144 @@ func main() {
 		cfg := config.New(ctx, "")
 		cfg.RequireObject("config", &configData)
 
-		// Create a VPC
-		awsVpc, err := ec2.NewVpc(ctx, configData.Vpc, &ec2.VpcArgs{
-			CidrBlock:       pulumi.String(configData.VpcCidar),
-			InstanceTenancy: pulumi.String(configData.VpcInstanceTenancy),
-			Tags: pulumi.StringMap{
-				"Name": pulumi.String(configData.Vpc),
-			},
-		})
-		if err != nil {
-			return err
-		}
-
-		// Check for availability zones based on the region
-		availableZones, err := aws.GetAvailabilityZones(ctx, nil, nil)
-		if err != nil {
-			return err
-		}
-
-		// Validation on MaxAvailabilityZones
-		if configData.MaxAvailabilityZones == 0 || configData.MaxAvailabilityZones > len(availableZones.Names) {
-			return errors.New(`{"status": 400, "msg": "Not sufficient AvailabilityZones"}`)
-		}
-
-		// Validation on BitsToMask
-		if configData.BitsToMask == 0 || configData.BitsToMask > 32 {
-			return errors.New(`{"status": 400, "msg": "Incorrect param bits_to_mask."}`)
-		}
-
-		// Assign AvailabilityZones based on our config requirements
-		if availableZones != nil {
-			if len(availableZones.Names) > configData.MaxAvailabilityZones {
-				configData.AvailabilityZones = availableZones.Names[0:configData.MaxAvailabilityZones]
-			} else {
-				configData.AvailabilityZones = availableZones.Names
-			}
-		}
-
-		// Calculate subnet cidr given VPC cidr, number of subnets required and bits to mask.
-		subnets, err := CalculateCIDRSubnets(configData.VpcCidar, len(configData.AvailabilityZones)*2, configData.BitsToMask)
-		if err != nil {
-			return err
-		}
-
-		// Create InternetGateway
-		awsIGW, err := ec2.NewInternetGateway(ctx, configData.InternetGateway, &ec2.InternetGatewayArgs{
-			Tags: pulumi.StringMap{
-				"Name": pulumi.String(configData.InternetGateway),
-			},
-		})
-		if err != nil {
-			return err
-		}
-
-		// Attach InternetGateway to VPC
-		_, err = ec2.NewInternetGatewayAttachment(ctx, configData.InternetGatewayAttachment, &ec2.InternetGatewayAttachmentArgs{
-			InternetGatewayId: awsIGW.ID(),
-			VpcId:             awsVpc.ID(),
-		})
-		if err != nil {
-			return err
-		}
-
-		var publicSubnets []pulumi.IDOutput
-		var privateSubnets []pulumi.IDOutput
-
-		// Iterate over cidrBlocksPublic to create a public subnets in each availability zones under an existing vpc
-		noOfAvailabilityZones := len(configData.AvailabilityZones)
-		for i := 0; i < noOfAvailabilityZones; i++ {
-			cidr := subnets[i]
-			subnetName := fmt.Sprintf(configData.PublicSubnetsPrefix+"-%d", i)
-			publicSubnet, err := ec2.NewSubnet(ctx, subnetName, &ec2.SubnetArgs{
-				VpcId:            awsVpc.ID(),
-				AvailabilityZone: pulumi.String(configData.AvailabilityZones[i]),
-				CidrBlock:        pulumi.String(cidr),
-				Tags: pulumi.StringMap{
-					"Name": pulumi.String(subnetName),
-				},
-			})
-			if err != nil {
-				return err
-			}
-
-			publicSubnets = append(publicSubnets, publicSubnet.ID())
-		}
-
-		// Iterate over cidrBlocksPrivate to create a private subnets in each availability zones under an existing vpc
-		for i := 0; i < noOfAvailabilityZones; i++ {
-			cidr := subnets[noOfAvailabilityZones+i]
-			subnetName := fmt.Sprintf(configData.PrivateSubnetPrefix+"-%d", i)
-			privateSubnet, err := ec2.NewSubnet(ctx, subnetName, &ec2.SubnetArgs{
-				VpcId:            awsVpc.ID(),
-				AvailabilityZone: pulumi.String(configData.AvailabilityZones[i]),
-				CidrBlock:        pulumi.String(cidr),
-				Tags: pulumi.StringMap{
-					"Name": pulumi.String(subnetName),
-				},
-			})
-			if err != nil {
-				return err
-			}
-
-			privateSubnets = append(privateSubnets, privateSubnet.ID())
-		}
-
-		// Create a public route table to an existing vpc
-		publicRouteTable, err := ec2.NewRouteTable(ctx, configData.PublicRouteTable, &ec2.RouteTableArgs{
-			VpcId: awsVpc.ID(),
-			Tags: pulumi.StringMap{
-				"Name": pulumi.String(configData.PublicRouteTable),
-			},
-		})
-		if err != nil {
-			return err
-		}
-
-		// Create a private route table to an existing vpc
-		privateRouteTable, err := ec2.NewRouteTable(ctx, configData.PrivateRouteTable, &ec2.RouteTableArgs{
-			VpcId: awsVpc.ID(),
-			Tags: pulumi.StringMap{
-				"Name": pulumi.String(configData.PrivateRouteTable),
-			},
-		})
-		if err != nil {
-			return err
-		}
-
-		// Create a route for the public route table to an Internet Gateway (for public subnets)
-		_, err = ec2.NewRoute(ctx, configData.PublicRoute, &ec2.RouteArgs{
-			RouteTableId:         publicRouteTable.ID(),
-			DestinationCidrBlock: pulumi.String(configData.PublicDestinationCidar),
-			GatewayId:            awsIGW.ID(),
-		})
-		if err != nil {
-			return err
-		}
-
-		// Associate the public route table with public subnets
-		for i, publicSubnetID := range publicSubnets {
-			_, err := ec2.NewRouteTableAssociation(ctx, fmt.Sprintf(configData.PublicRouteTableSubnetsAssociationPrefix+"-%d", i), &ec2.RouteTableAssociationArgs{
-				SubnetId:     publicSubnetID,
-				RouteTableId: publicRouteTable.ID(),
-			})
-			if err != nil {
-				return err
-			}
-		}
-
-		// Create separate route tables for private subnets
-		for i, privateSubnetID := range privateSubnets {
-			// Associate each private route table with its respective private subnet
-			_, err = ec2.NewRouteTableAssociation(ctx, fmt.Sprintf(configData.PrivateRouteTableSubnetsAssociationPrefix+"-%d", i), &ec2.RouteTableAssociationArgs{
-				SubnetId:     privateSubnetID,
-				RouteTableId: privateRouteTable.ID(),
-			})
-			if err != nil {
-				return err
-			}
-		}
-
-		// Fetch the public IP address of the system and allow only that IP to connect through SSH
-		systemPublicIP, err := getPublicIPV4(configData.FetchPublicIPURL)
-		if err != nil {
-			return err
-		}
-
-		systemPublicIP = systemPublicIP + "/32"
-
-		// Create a new security group for load balancer
-		loadBalancerSecurityGroup, err := ec2.NewSecurityGroup(ctx, "load-balancer-security-group", &ec2.SecurityGroupArgs{
-			VpcId: awsVpc.ID(),
-			Tags: pulumi.StringMap{
-				"Name": pulumi.String("load-balancer-security-group"),
-			},
-			Ingress: ec2.SecurityGroupIngressArray{
-				//&ec2.SecurityGroupIngressArgs{
-				//	Description: pulumi.String("Allow inbound HTTP traffic on port 80 from all public IP addresses"),
-				//	FromPort:    pulumi.Int(configData.InboundPorts["http"]),
-				//	ToPort:      pulumi.Int(configData.InboundPorts["http"]),
-				//	Protocol:    pulumi.String(configData.SecurityRuleProtocol),
-				//	CidrBlocks:  pulumi.StringArray{pulumi.String(configData.PublicDestinationCidar)},
-				//},
-				&ec2.SecurityGroupIngressArgs{
-					Description: pulumi.String("Allow inbound HTTPS traffic on port 443 from all public IP addresses"),
-					FromPort:    pulumi.Int(configData.InboundPorts["https"]),
-					ToPort:      pulumi.Int(configData.InboundPorts["https"]),
-					Protocol:    pulumi.String(configData.SecurityRuleProtocol),
-					CidrBlocks:  pulumi.StringArray{pulumi.String(configData.PublicDestinationCidar)},
-				},
-			},
-			Egress: ec2.SecurityGroupEgressArray{
-				&ec2.SecurityGroupEgressArgs{
-					FromPort:   pulumi.Int(8080),
-					ToPort:     pulumi.Int(8080),
-					Protocol:   pulumi.String(configData.SecurityRuleProtocol),
-					CidrBlocks: pulumi.StringArray{pulumi.String(configData.PublicDestinationCidar)},
-				},
-				//&ec2.SecurityGroupEgressArgs{
-				//	FromPort:   pulumi.Int(0),
-				//	ToPort:     pulumi.Int(0),
-				//	Protocol:   pulumi.String("-1"),
-				//	CidrBlocks: pulumi.StringArray{pulumi.String(configData.PublicDestinationCidar)},
-				//},
-			},
-		})
-		if err != nil {
-			return err
-		}
-
-		// Create a new security group for application running in EC2
-		appSecurityGroup, err := ec2.NewSecurityGroup(ctx, configData.SecurityGroup, &ec2.SecurityGroupArgs{
-			VpcId: awsVpc.ID(),
-			Tags: pulumi.StringMap{
-				"Name": pulumi.String(configData.SecurityGroup),
-			},
-			Ingress: ec2.SecurityGroupIngressArray{
-				&ec2.SecurityGroupIngressArgs{
-					Description: pulumi.String("Allow inbound HTTPS traffic on port 8080 from public all public IP addresses"),
-					FromPort:    pulumi.Int(configData.InboundPorts["customPort"]),
-					ToPort:      pulumi.Int(configData.InboundPorts["customPort"]),
-					Protocol:    pulumi.String(configData.SecurityRuleProtocol),
-					SecurityGroups: pulumi.StringArray{
-						loadBalancerSecurityGroup.ID(),
-					},
-				},
-				&ec2.SecurityGroupIngressArgs{
-					Description: pulumi.String("Allow inbound SSH traffic on port 22 from custom IP"),
-					FromPort:    pulumi.Int(configData.InboundPorts["ssh"]),
-					ToPort:      pulumi.Int(configData.InboundPorts["ssh"]),
-					Protocol:    pulumi.String(configData.SecurityRuleProtocol),
-					SecurityGroups: pulumi.StringArray{
-						loadBalancerSecurityGroup.ID(),
-					},
-					//CidrBlocks: pulumi.StringArray{pulumi.String(configData.PublicDestinationCidar)},
-				},
-			},
-			Egress: ec2.SecurityGroupEgressArray{
-				&ec2.SecurityGroupEgressArgs{
-					FromPort:   pulumi.Int(0),
-					ToPort:     pulumi.Int(0),
-					Protocol:   pulumi.String("-1"),
-					CidrBlocks: pulumi.StringArray{pulumi.String(configData.PublicDestinationCidar)},
-				},
-			},
-		})
-		if err != nil {
-			return err
-		}
-
-		// Create a custom parameter group to configure custom RDS Instance
-		rdsParameterGroup, err := rds.NewParameterGroup(ctx, "webapp-parameter-group", &rds.ParameterGroupArgs{
-			Description: pulumi.String("Custom parameter group for webapp rds instance"),
-			Family:      pulumi.String("postgres15"),
-			Name:        pulumi.String("webapp-rds-parameter-group"),
-			Parameters: rds.ParameterGroupParameterArray{
-				&rds.ParameterGroupParameterArgs{
-					Name:  pulumi.String("rds.force_ssl"),
-					Value: pulumi.String("0"),
-				},
-			},
-		})
-		if err != nil {
-			return err
-		}
-
-		// Create a custom security group for the RDS instance.
-		databaseSecurityGroup, err := ec2.NewSecurityGroup(ctx, configData.RDSInstanceMetadata.SecurityGroupName, &ec2.SecurityGroupArgs{
-			VpcId:       awsVpc.ID(),
-			Description: pulumi.String("Custom RDS Security Group"),
-			Tags: pulumi.StringMap{
-				"Name": pulumi.String("database-security-group"),
-			},
-			Ingress: ec2.SecurityGroupIngressArray{
-				&ec2.SecurityGroupIngressArgs{
-					Description: pulumi.String("Allow traffic from resources that use appSecurityGroup through 5432 port"),
-					FromPort:    pulumi.Int(configData.RDSInstanceMetadata.AllowsPort),
-					ToPort:      pulumi.Int(configData.RDSInstanceMetadata.AllowsPort),
-					Protocol:    pulumi.String(configData.RDSInstanceMetadata.Protocol),
-					SecurityGroups: pulumi.StringArray{
-						appSecurityGroup.ID(),
-					},
-				},
-			},
-		})
-		if err != nil {
-			return err
-		}
-
-		_, err = ec2.NewSecurityGroupRule(ctx, "AllowOutboundToDB", &ec2.SecurityGroupRuleArgs{
-			Type:                  pulumi.String("egress"),
-			FromPort:              pulumi.Int(configData.RDSInstanceMetadata.AllowsPort),
-			ToPort:                pulumi.Int(configData.RDSInstanceMetadata.AllowsPort),
-			Protocol:              pulumi.String(configData.RDSInstanceMetadata.Protocol),
-			SourceSecurityGroupId: databaseSecurityGroup.ID(),
-			SecurityGroupId:       appSecurityGroup.ID(),
-		})
-		if err != nil {
-			return err
-		}
-
-		_, err = ec2.NewSecurityGroupRule(ctx, "AllowOutboundToInternet", &ec2.SecurityGroupRuleArgs{
-			Type:            pulumi.String("egress"),
-			FromPort:        pulumi.Int(443),
-			ToPort:          pulumi.Int(443),
-			Protocol:        pulumi.String(configData.RDSInstanceMetadata.Protocol),
-			CidrBlocks:      pulumi.StringArray{pulumi.String(configData.PublicDestinationCidar)},
-			SecurityGroupId: appSecurityGroup.ID(),
-		})
-		if err != nil {
-			return err
-		}
-
-		// create a Subnet Group for all private subnets under a VPC.
-		privateSubnetsStrs := make(pulumi.StringArray, len(privateSubnets))
-		publicSubnetsStrs := make(pulumi.StringArray, len(publicSubnets))
-		for _, subnet := range privateSubnets {
-			privateSubnetsStrs = append(privateSubnetsStrs, subnet.ToIDOutput())
-		}
+		return createInfrastructure(ctx, configData)
+	})
+}
 
-		for _, subnet := range publicSubnets {
-			publicSubnetsStrs = append(publicSubnetsStrs, subnet.ToIDOutput())
+// createInfrastructure creates all the resources of the stack from the given config and exports the stack outputs,
+// kept separate from main so that it can run against mocked resource monitors.
+func createInfrastructure(ctx *pulumi.Context, configData Data) error {
+	// Tag every resource with the mandatory tags, registered first so that it applies to all of them
+	if err := tagging.ApplyMandatoryTags(ctx, configData.Tags); err != nil {
+		return err
+	}
+
+	// Physical names include the project, stack and region so that stacks sharing an account don't collide
+	namer := naming.NewFromContext(ctx, configData.ResourceParams.Region, configData.Naming)
+
+	// Create the VPC with a public and a private subnet in each availability zone
+	vpcNetwork, err := network.NewNetwork(ctx, "webapp-network", &network.NetworkArgs{
+		VpcName:                       configData.Vpc,
+		CidrBlock:                     configData.VpcCidar,
+		InstanceTenancy:               configData.VpcInstanceTenancy,
+		MaxAvailabilityZones:          configData.MaxAvailabilityZones,
+		BitsToMask:                    configData.BitsToMask,
+		InternetGatewayName:           configData.InternetGateway,
+		InternetGatewayAttachmentName: configData.InternetGatewayAttachment,
+		PublicRouteName:               configData.PublicRoute,
+		PublicRouteTableName:          configData.PublicRouteTable,
+		PrivateRouteTableName:         configData.PrivateRouteTable,
+		PublicDestinationCidr:         configData.PublicDestinationCidar,
+		PublicSubnetsPrefix:           configData.PublicSubnetsPrefix,
+		PrivateSubnetsPrefix:          configData.PrivateSubnetPrefix,
+		PublicRouteTableAssocPrefix:   configData.PublicRouteTableSubnetsAssociationPrefix,
+		PrivateRouteTableAssocPrefix:  configData.PrivateRouteTableSubnetsAssociationPrefix,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Fetch the public IP address of the system and allow only that IP to connect through SSH
+	systemPublicIP, err := getPublicIPV4(configData.FetchPublicIPURL)
+	if err != nil {
+		return err
+	}
+
+	systemPublicIP = systemPublicIP + "/32"
+
+	// Open the ports of the additional target groups between the load balancer and the app
+	var targetPorts []int
+	for _, targetGroup := range configData.LoadBalancer.TargetGroups {
+		if targetGroup.Port != configData.InboundPorts["customPort"] && !slices.Contains(targetPorts, targetGroup.Port) {
+			targetPorts = append(targetPorts, targetGroup.Port)
 		}
+	}
+
+	// The database component is created further down at the top level of the stack, the security groups need its URN
+	// to keep the one the proxy security group had under it
+	databaseURN := pulumi.CreateURN(pulumi.String(databaseComponentName), pulumi.String(database.ComponentType), nil,
+		pulumi.String(ctx.Project()), pulumi.String(ctx.Stack()))
+
+	// Create the load balancer, app and database security groups
+	securityGroups, err := securitygroups.NewSecurityGroups(ctx, "webapp-security-groups", &securitygroups.SecurityGroupsArgs{
+		VpcID:                     vpcNetwork.VpcID,
+		AppSecurityGroupName:      configData.SecurityGroup,
+		DatabaseSecurityGroupName: configData.RDSInstanceMetadata.SecurityGroupName,
+		RuleProtocol:              configData.SecurityRuleProtocol,
+		PublicDestinationCidr:     configData.PublicDestinationCidar,
+		InboundPorts:              configData.InboundPorts,
+		DatabasePort:              configData.RDSInstanceMetadata.AllowsPort,
+		DatabaseProtocol:          configData.RDSInstanceMetadata.Protocol,
+		HTTPRedirect:              configData.LoadBalancer.HTTPRedirect,
+		TargetPorts:               targetPorts,
+		RDSProxy:                  configData.RDSInstanceMetadata.Proxy.Enabled,
+		ProxySecurityGroupName:    configData.RDSInstanceMetadata.Proxy.SecurityGroupName,
+		VpcCidr:                   configData.VpcCidar,
+		ProxyPreviousParent:       databaseURN,
+	})
+	if err != nil {
+		return err
+	}
 
-		privateRDSSubnetGrp, err := rds.NewSubnetGroup(ctx, configData.RDSInstanceMetadata.SubnetGrp, &rds.SubnetGroupArgs{
-			SubnetIds: privateSubnetsStrs,
-			Tags: pulumi.StringMap{
-				"Name": pulumi.String("database-private-subnet-grp"),
-			},
-		})
-		if err != nil {
-			return err
-		}
+	// The instance role comes first, the KMS key policies and the messaging policy reference it
+	instanceRole, err := apptier.NewInstanceRole(ctx, "webapp-instance-role", &apptier.InstanceRoleArgs{
+		RoleName: "ec2CloudWatchRole",
+	})
+	if err != nil {
+		return err
+	}
+
+	// Create the customer managed KMS keys, the key policy grants the EC2 role use of the keys
+	kmsKeys, err := encryption.NewKMSKeys(ctx, "webapp-kms-keys", &encryption.KMSKeysArgs{
+		Config:     configData.Kms,
+		AccountID:  configData.ResourceParams.AccountID,
+		Region:     configData.ResourceParams.Region,
+		EC2RoleArn: instanceRole.RoleArn,
+		Namer:      namer,
+	})
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDatabase(ctx, databaseComponentName, &database.DatabaseArgs{
+		Metadata:                configData.RDSInstanceMetadata,
+		Region:                  configData.ResourceParams.Region,
+		SubnetIDs:               vpcNetwork.PrivateSubnetIDs,
+		DatabaseSecurityGroupID: securityGroups.DatabaseSecurityGroupID,
+		ProxySecurityGroupID:    securityGroups.ProxySecurityGroupID,
+		KmsKey:                  kmsKeys.RDS,
+		AlertKmsKey:             kmsKeys.SNS,
+		Namer:                   namer,
+	})
+	if err != nil {
+		return err
+	}
+
+	topic, err := messaging.NewMessaging(ctx, "webapp-messaging", &messaging.MessagingArgs{
+		TopicName:       configData.ResourceParams.SNSTopic,
+		Region:          configData.ResourceParams.Region,
+		AccountID:       configData.ResourceParams.AccountID,
+		KmsKey:          kmsKeys.SNS,
+		PublisherRoleID: instanceRole.RoleID,
+		Namer:           namer,
+	})
+	if err != nil {
+		return err
+	}
 
-		// Create the RDS instance with the custom security group and parameter group.
-		rdsInstance, err := rds.NewInstance(ctx, configData.RDSInstanceMetadata.InstanceName, &rds.InstanceArgs{
-			Engine:             pulumi.String(configData.RDSInstanceMetadata.Engine),
-			EngineVersion:      pulumi.String(configData.RDSInstanceMetadata.EngineVersion),
-			InstanceClass:      pulumi.String(configData.RDSInstanceMetadata.InstanceClass),
-			AllocatedStorage:   pulumi.Int(configData.RDSInstanceMetadata.AllowedStorage),
-			ApplyImmediately:   pulumi.Bool(true),
-			Identifier:         pulumi.String(configData.RDSInstanceMetadata.Identifier),
-			Username:           pulumi.String(configData.RDSInstanceMetadata.Username),
-			Password:           pulumi.String(configData.RDSInstanceMetadata.Password),
-			DbName:             pulumi.String(configData.RDSInstanceMetadata.DbName),
-			ParameterGroupName: pulumi.StringPtrInput(rdsParameterGroup.Name),
-			DbSubnetGroupName:  pulumi.StringInput(privateRDSSubnetGrp.Name),
-			PubliclyAccessible: pulumi.Bool(configData.RDSInstanceMetadata.PubliclyAccessible),
-			MultiAz:            pulumi.Bool(configData.RDSInstanceMetadata.MultiAz),
-			SkipFinalSnapshot:  pulumi.Bool(configData.RDSInstanceMetadata.SkipFinalSnapShot),
-			StorageEncrypted:   pulumi.Bool(configData.RDSInstanceMetadata.StorageEncrypted),
-			VpcSecurityGroupIds: pulumi.StringArray{
-				databaseSecurityGroup.ID(),
-			},
-		})
-		if err != nil {
-			return err
-		}
+	// Build the user data from the database endpoints once they are known, the launch template consumes it as an Output
+	userData := pulumi.All(db.WriterHost, db.ReaderHosts, topic.TopicArn).ApplyT(func(args []interface{}) string {
+		rdsEndpoint := args[0].(string)
+		rdsReadHosts := strings.Join(args[1].([]string), ",")
+		topicArn := args[2].(string)
 
-		rdsInstance.Endpoint.ApplyT(func(rdsEndpoint string) error {
-			rdsEndpoint = strings.Split(rdsEndpoint, ":")[0]
-
-			// Read the public key content from the file.
-			publicKeyContent, err := os.ReadFile(configData.EC2InstanceMetadata.PublicKeyFilePath)
-			if err != nil {
-				return err
-			}
-
-			// Create an EC2 key pair.
-			_, err = ec2.NewKeyPair(ctx, configData.EC2InstanceMetadata.SSHKeyName, &ec2.KeyPairArgs{
-				KeyName:   pulumi.String(configData.EC2InstanceMetadata.SSHKeyName),
-				PublicKey: pulumi.String(publicKeyContent),
-			})
-			if err != nil {
-				return err
-			}
-
-			// Create IAM role for EC2 instance
-			ec2CloudWatchRoleStr, err := json.Marshal(map[string]interface{}{
-				"Version": "2012-10-17",
-				"Statement": []map[string]interface{}{
-					{
-						"Effect": "Allow",
-						"Action": []string{"sts:AssumeRole"},
-						"Principal": map[string]interface{}{
-							"Service": []string{"ec2.amazonaws.com"},
-						},
-					},
-				},
-			})
-
-			role, err := iam.NewRole(ctx, "ec2CloudWatchRole", &iam.RoleArgs{
-				AssumeRolePolicy: pulumi.String(ec2CloudWatchRoleStr),
-				Tags: pulumi.StringMap{
-					"tag-key": pulumi.String("ec2-cloudwatch-role"),
-				},
-			})
-			if err != nil {
-				return err
-			}
-
-			// Attach CloudWatchAgentServerPolicy to the new role
-			_, err = iam.NewRolePolicyAttachment(ctx, "ec2CloudWatchPolicy", &iam.RolePolicyAttachmentArgs{
-				Role:      role.ID(),
-				PolicyArn: pulumi.String("arn:aws:iam::aws:policy/CloudWatchAgentServerPolicy"),
-			})
-			if err != nil {
-				return err
-			}
-
-			snsAccessPolicy, err := json.Marshal(map[string]interface{}{
-				"Version": "2008-10-17",
-				"Id":      "__default_policy_ID",
-				"Statement": []map[string]interface{}{
-					{
-						"Sid":    "__default_statement_ID",
-						"Effect": "Allow",
-						"Principal": map[string]string{
-							"AWS": "*",
-						},
-						"Action": []string{
-							"SNS:Publish",
-							"SNS:RemovePermission",
-							"SNS:SetTopicAttributes",
-							"SNS:DeleteTopic",
-							"SNS:ListSubscriptionsByTopic",
-							"SNS:GetTopicAttributes",
-							"SNS:AddPermission",
-							"SNS:Subscribe",
-						},
-						"Resource": fmt.Sprintf("arn:aws:sns:%v:%v:%v", configData.ResourceParams.Region, configData.ResourceParams.AccountID, configData.ResourceParams.SNSTopic),
-						"Condition": map[string]interface{}{
-							"StringEquals": map[string]string{
-								"AWS:SourceOwner": configData.ResourceParams.AccountID,
-							},
-						},
-					},
-					//{
-					//	"Sid":    "__console_pub_0",
-					//	"Effect": "Allow",
-					//	"Principal": map[string]interface{}{
-					//		"AWS": configData.ResourceParams.AccountID,
-					//	},
-					//	"Action":   "SNS:Publish",
-					//	"Resource": fmt.Sprintf("arn:aws:sns:%v:%v:%v", configData.ResourceParams.Region, configData.ResourceParams.AccountID, configData.ResourceParams.SNSTopic),
-					//},
-					//{
-					//	"Sid":    "__console_sub_0",
-					//	"Effect": "Allow",
-					//	"Principal": map[string]interface{}{
-					//		"AWS": configData.ResourceParams.AccountID,
-					//	},
-					//	"Action": []string{
-					//		"SNS:Subscribe",
-					//	},
-					//	"Resource": fmt.Sprintf("arn:aws:sns:%v:%v:%v", configData.ResourceParams.Region, configData.ResourceParams.AccountID, configData.ResourceParams.SNSTopic),
-					//},
-				},
-			})
-			if err != nil {
-				return err
-			}
-
-			_, err = sns.NewTopic(ctx, "testSNSTopic", &sns.TopicArgs{
-				Name:        pulumi.String(configData.ResourceParams.SNSTopic),
-				DisplayName: pulumi.String("submissions"),
-				FifoTopic:   pulumi.Bool(false),
-				Policy:      pulumi.String(snsAccessPolicy),
-			})
-			if err != nil {
-				return err
-			}
-
-			snsPolicyStr, err := json.Marshal(map[string]interface{}{
-				"Version": "2012-10-17",
-				"Statement": []map[string]interface{}{
-					{
-						"Sid":    "VisualEditor0",
-						"Effect": "Allow",
-						"Action": []string{
-							"sns:Publish",
-							"sns:Subscribe",
-						},
-						"Resource": fmt.Sprintf("arn:aws:sns:%v:%v:%v", configData.ResourceParams.Region, configData.ResourceParams.AccountID, configData.ResourceParams.SNSTopic),
-					},
-					{
-						"Sid": "VisualEditor1",
-						"Action": []string{
-							"sns:ListTopics",
-							"sns:Unsubscribe",
-							"sns:ListSubscriptions",
-						},
-						"Effect":   "Allow",
-						"Resource": "*",
-					},
-				},
-			})
-			if err != nil {
-				return err
-			}
-
-			customSNSPolicy, err := iam.NewPolicy(ctx, "CustomSNSPolicy2", &iam.PolicyArgs{
-				Path:        pulumi.String("/"),
-				Description: pulumi.String("Custom SNS policy to publish message from EC2 to SNS Topic"),
-				Policy:      pulumi.String(snsPolicyStr),
-			})
-			if err != nil {
-				return err
-			}
-
-			_, err = iam.NewRolePolicyAttachment(ctx, "ec2SNSPolicy", &iam.RolePolicyAttachmentArgs{
-				Role:      role.ID(),
-				PolicyArn: customSNSPolicy.Arn,
-			})
-			if err != nil {
-				return err
-			}
-
-			instanceProfile, err := iam.NewInstanceProfile(ctx, "ec2CloudWatchProfile", &iam.InstanceProfileArgs{
-				Role: role.Name,
-			})
-			if err != nil {
-				return err
-			}
-
-			// Create an EC2 instance
-			userData := fmt.Sprintf(`#!/bin/bash
+		envFile := fmt.Sprintf(`#!/bin/bash
 ENV_FILE="/opt/webapp.dev.env"
 sudo echo "PORT=%v" >> ${ENV_FILE}
 sudo echo "DB_USER=%v" >> ${ENV_FILE}
 sudo echo "DB_PASS=%v" >> ${ENV_FILE}
 sudo echo "DB_HOST='%v'" >> ${ENV_FILE}
+sudo echo "DB_READ_HOSTS='%v'" >> ${ENV_FILE}
 sudo echo "DB_PORT=%v" >> ${ENV_FILE}
 sudo echo "DB_NAME=%v" >> ${ENV_FILE}
 sudo echo "DRIVER_NAME=%v" >> ${ENV_FILE}
@@ -671,263 +254,131 @@ sudo chmod 644 ${ENV_FILE}
 sudo /opt/aws/amazon-cloudwatch-agent/bin/amazon-cloudwatch-agent-ctl -a fetch-config -m ec2 -c file:/home/ec2-user/webapp/observability-config.json -s
 sudo systemctl restart amazon-cloudwatch-agent
 `, configData.InboundPorts["customPort"], configData.RDSInstanceMetadata.Username,
-				configData.RDSInstanceMetadata.Password, rdsEndpoint, configData.RDSInstanceMetadata.AllowsPort,
-				configData.RDSInstanceMetadata.DbName, configData.RDSInstanceMetadata.DbDriver,
-				configData.EC2InstanceMetadata.UserDataFilePath, configData.EC2InstanceMetadata.MigrationsFilePath,
-				configData.EC2InstanceMetadata.LogFilePath, configData.EC2InstanceMetadata.MetricServerPort,
-				configData.MailerClientCreds.APIKey, configData.MailerClientCreds.Domain, configData.MailerClientCreds.Email,
-				fmt.Sprintf("arn:aws:sns:%v:%v:%v", configData.ResourceParams.Region, configData.ResourceParams.AccountID, configData.ResourceParams.SNSTopic))
-
-			//webappInstance, err := ec2.NewInstance(ctx, configData.EC2InstanceMetadata.InstanceName, &ec2.InstanceArgs{
-			//	InstanceType:             pulumi.String(configData.EC2InstanceMetadata.InstanceType),
-			//	AssociatePublicIpAddress: pulumi.Bool(configData.EC2InstanceMetadata.AssociatePublicIpAddress),
-			//	KeyName:                  pulumi.String(configData.EC2InstanceMetadata.SSHKeyName),
-			//	Ami:                      pulumi.String(configData.EC2InstanceMetadata.AmiID),
-			//	SubnetId:                 publicSubnets[0],
-			//	UserData:                 pulumi.String(userData),
-			//	VpcSecurityGroupIds:      pulumi.StringArray{appSecurityGroup.ID()},
-			//	IamInstanceProfile:       instanceProfile.Name,
-			//	EbsBlockDevices: ec2.InstanceEbsBlockDeviceArray{
-			//		&ec2.InstanceEbsBlockDeviceArgs{
-			//			DeviceName:          pulumi.String(configData.EC2InstanceMetadata.DeviceType),
-			//			VolumeType:          pulumi.String(configData.EC2InstanceMetadata.VolumeType),        // Use General Purpose SSD (GP2)
-			//			VolumeSize:          pulumi.Int(configData.EC2InstanceMetadata.VolumeSize),           // Set root volume size to 25 GB
-			//			DeleteOnTermination: pulumi.Bool(configData.EC2InstanceMetadata.DeleteOnTermination), // Root volume is deleted when instance is terminated
-			//		},
-			//	},
-			//	DisableApiTermination: pulumi.Bool(configData.EC2InstanceMetadata.DisableApiTermination), // Protect against accidental termination is set to "No"
-			//	Tags: pulumi.StringMap{
-			//		"Name": pulumi.String(configData.EC2InstanceMetadata.InstanceName),
-			//	},
-			//})
-			//if err != nil {
-			//	return err
-			//}
-
-			appLoadBalancer, err := lb.NewLoadBalancer(ctx, "test", &lb.LoadBalancerArgs{
-				Name:             pulumi.String("app-load-balancer"),
-				Internal:         pulumi.Bool(false),
-				LoadBalancerType: pulumi.String("application"),
-				SecurityGroups: pulumi.StringArray{
-					loadBalancerSecurityGroup.ID(),
-				},
-				Subnets: publicSubnetsStrs,
-				Tags: pulumi.StringMap{
-					"Name": pulumi.String("foobar-elb"),
-				},
-			})
-			if err != nil {
-				return err
-			}
-
-			appLoadBalancerTargetGroup, err := lb.NewTargetGroup(ctx, "test", &lb.TargetGroupArgs{
-				Name:       pulumi.String("app-loadbalancer-tg"),
-				Port:       pulumi.Int(8080),
-				Protocol:   pulumi.String("HTTP"),
-				TargetType: pulumi.String("instance"),
-				HealthCheck: lb.TargetGroupHealthCheckArgs{
-					Enabled:            pulumi.Bool(true),
-					Path:               pulumi.String("/healthz"),
-					Port:               pulumi.String("traffic-port"),
-					Protocol:           pulumi.String("HTTP"),
-					HealthyThreshold:   pulumi.Int(2),
-					UnhealthyThreshold: pulumi.Int(2),
-					Timeout:            pulumi.Int(3),
-					Interval:           pulumi.Int(30),
-				},
-				VpcId: awsVpc.ID(),
-			})
-			if err != nil {
-				return err
-			}
-
-			_, err = lb.NewListener(ctx, "frontEndListener", &lb.ListenerArgs{
-				LoadBalancerArn: appLoadBalancer.Arn,
-				//Port:            pulumi.Int(80),
-				//Protocol:        pulumi.String("HTTP"),
-				Port:           pulumi.Int(443),
-				CertificateArn: pulumi.String(configData.ResourceParams.CertificateArn),
-				SslPolicy:      pulumi.String("ELBSecurityPolicy-TLS13-1-2-2021-06"),
-				Protocol:       pulumi.String("HTTPS"),
-				DefaultActions: lb.ListenerDefaultActionArray{
-					&lb.ListenerDefaultActionArgs{
-						Type:           pulumi.String("forward"),
-						TargetGroupArn: appLoadBalancerTargetGroup.Arn,
-					},
-				},
-			})
-			if err != nil {
-				return err
-			}
-
-			launchTemplate, err := ec2.NewLaunchTemplate(ctx, "example_launch_template", &ec2.LaunchTemplateArgs{
-				Name:                  pulumi.String("asg_launch_config"),
-				InstanceType:          pulumi.String(configData.EC2InstanceMetadata.InstanceType),
-				KeyName:               pulumi.String(configData.EC2InstanceMetadata.SSHKeyName),
-				ImageId:               pulumi.String(configData.EC2InstanceMetadata.AmiID),
-				IamInstanceProfile:    &ec2.LaunchTemplateIamInstanceProfileArgs{Name: instanceProfile.Name},
-				UserData:              pulumi.String(base64.StdEncoding.EncodeToString([]byte(userData))),
-				DisableApiTermination: pulumi.Bool(configData.EC2InstanceMetadata.DisableApiTermination),
-				Tags: pulumi.StringMap{
-					"Name": pulumi.String(configData.EC2InstanceMetadata.InstanceName),
-				},
-				NetworkInterfaces: ec2.LaunchTemplateNetworkInterfaceArray{
-					&ec2.LaunchTemplateNetworkInterfaceArgs{
-						AssociatePublicIpAddress: pulumi.String("true"),
-						SecurityGroups: pulumi.StringArray{
-							appSecurityGroup.ID(),
-						},
-					},
-				},
-				BlockDeviceMappings: ec2.LaunchTemplateBlockDeviceMappingArray{
-					&ec2.LaunchTemplateBlockDeviceMappingArgs{
-						DeviceName: pulumi.String(configData.EC2InstanceMetadata.DeviceType),
-						Ebs: &ec2.LaunchTemplateBlockDeviceMappingEbsArgs{
-							VolumeType:          pulumi.String(configData.EC2InstanceMetadata.VolumeType), // Use General Purpose SSD (GP2)
-							VolumeSize:          pulumi.Int(configData.EC2InstanceMetadata.VolumeSize),    // Set root volume size to 25 GB
-							DeleteOnTermination: pulumi.String("true"),                                    // Root volume is deleted when instance is terminated
-						},
-					},
-				},
-			})
-			if err != nil {
-				return err
-			}
-
-			launchTemplateVersion := launchTemplate.LatestVersion.ApplyT(func(num int) string {
-				return strconv.Itoa(num)
-			}).(pulumi.StringOutput)
-
-			autoscalingGroup, err := autoscaling.NewGroup(ctx, "example_auto_scaling_group", &autoscaling.GroupArgs{
-				Name:               pulumi.String("webapp-auto-scaling-group"),
-				VpcZoneIdentifiers: publicSubnetsStrs,
-				DefaultCooldown:    pulumi.IntPtr(60),
-				DesiredCapacity:    pulumi.IntPtr(1),
-				MaxSize:            pulumi.Int(3),
-				MinSize:            pulumi.Int(1),
-				//HealthCheckType:        pulumi.String("EC2"),
-				HealthCheckGracePeriod: pulumi.Int(300),
-				Tags: autoscaling.GroupTagArray{
-					&autoscaling.GroupTagArgs{
-						Key:               pulumi.String("AutoScalingGroup"),
-						Value:             pulumi.String("TagProperty"),
-						PropagateAtLaunch: pulumi.Bool(true),
-					},
-				},
-				TargetGroupArns: pulumi.StringArray{
-					appLoadBalancerTargetGroup.Arn,
-				},
-				LaunchTemplate: &autoscaling.GroupLaunchTemplateArgs{
-					Id:      launchTemplate.ID(),
-					Version: launchTemplateVersion,
-				},
-			})
-			if err != nil {
-				return err
-			}
-
-			scaleUpPolicy, err := autoscaling.NewPolicy(ctx, "scale_up", &autoscaling.PolicyArgs{
-				AdjustmentType:       pulumi.String("ChangeInCapacity"),
-				ScalingAdjustment:    pulumi.Int(1),
-				Cooldown:             pulumi.Int(60),
-				AutoscalingGroupName: autoscalingGroup.Name,
-				PolicyType:           pulumi.String("SimpleScaling"),
-				//EstimatedInstanceWarmup: pulumi.Int(20),
-				//StepAdjustments: autoscaling.PolicyStepAdjustmentArray{
-				//	&autoscaling.PolicyStepAdjustmentArgs{
-				//		MetricIntervalUpperBound: pulumi.String("5"),
-				//		ScalingAdjustment:        pulumi.Int(1),
-				//	},
-				//},
-			})
-			if err != nil {
-				return err
-			}
-
-			scaleDownPolicy, err := autoscaling.NewPolicy(ctx, "scale_down", &autoscaling.PolicyArgs{
-				AdjustmentType:       pulumi.String("ChangeInCapacity"),
-				ScalingAdjustment:    pulumi.Int(-1),
-				Cooldown:             pulumi.Int(60),
-				AutoscalingGroupName: autoscalingGroup.Name,
-				PolicyType:           pulumi.String("SimpleScaling"),
-				//EstimatedInstanceWarmup: pulumi.Int(20),
-				//StepAdjustments: autoscaling.PolicyStepAdjustmentArray{
-				//	&autoscaling.PolicyStepAdjustmentArgs{
-				//		MetricIntervalUpperBound: pulumi.String("3"),
-				//		ScalingAdjustment:        pulumi.Int(-1),
-				//	},
-				//},
-			})
-			if err != nil {
-				return err
-			}
-
-			_, err = cloudwatch.NewMetricAlarm(ctx, "cpu_utilization_high_alarm", &cloudwatch.MetricAlarmArgs{
-				Name:               pulumi.String("cpu-high"),
-				ComparisonOperator: pulumi.String("GreaterThanOrEqualToThreshold"),
-				EvaluationPeriods:  pulumi.Int(1),
-				MetricName:         pulumi.String("CPUUtilization"),
-				Namespace:          pulumi.String("AWS/EC2"),
-				Period:             pulumi.Int(60),
-				Statistic:          pulumi.String("Average"),
-				Threshold:          pulumi.Float64(5),
-				Dimensions: pulumi.StringMap{
-					"AutoScalingGroupName": autoscalingGroup.Name,
-				},
-				AlarmDescription: pulumi.String("Alarm when CPU exceeds 3% threshold"),
-				AlarmActions: pulumi.Array{
-					scaleUpPolicy.Arn,
-				},
-			})
-
-			_, err = cloudwatch.NewMetricAlarm(ctx, "cpu_utilization_low_alarm", &cloudwatch.MetricAlarmArgs{
-				Name:               pulumi.String("cpu-low"),
-				ComparisonOperator: pulumi.String("LessThanOrEqualToThreshold"),
-				EvaluationPeriods:  pulumi.Int(1),
-				MetricName:         pulumi.String("CPUUtilization"),
-				Namespace:          pulumi.String("AWS/EC2"),
-				Period:             pulumi.Int(60),
-				Statistic:          pulumi.String("Average"),
-				Threshold:          pulumi.Float64(3),
-				Dimensions: pulumi.StringMap{
-					"AutoScalingGroupName": autoscalingGroup.Name,
-				},
-				AlarmDescription: pulumi.String("Alarm when CPU falls below 3% threshold"),
-				AlarmActions: pulumi.Array{
-					scaleDownPolicy.Arn,
-				},
-			})
-
-			_, err = route53.NewRecord(ctx, configData.Dns.ARecordName, &route53.RecordArgs{
-				Name:   pulumi.String(configData.Dns.Domain),
-				Type:   pulumi.String(configData.Dns.Type),
-				ZoneId: pulumi.String(configData.Dns.HostedZoneID),
-				//Records:        pulumi.StringArray{loadBalancer.DnsName},
-				//Ttl: pulumi.Int(configData.Dns.Ttl),
-				Aliases: route53.RecordAliasArray{
-					&route53.RecordAliasArgs{
-						Name:                 appLoadBalancer.DnsName,
-						ZoneId:               appLoadBalancer.ZoneId,
-						EvaluateTargetHealth: pulumi.Bool(true),
-					},
-				},
-				AllowOverwrite: pulumi.BoolPtr(true),
-			})
-			if err != nil {
-				return err
-			}
-
-			return nil
+			configData.RDSInstanceMetadata.Password, rdsEndpoint, rdsReadHosts, configData.RDSInstanceMetadata.AllowsPort,
+			configData.RDSInstanceMetadata.DbName, configData.RDSInstanceMetadata.DbDriver,
+			configData.EC2InstanceMetadata.UserDataFilePath, configData.EC2InstanceMetadata.MigrationsFilePath,
+			configData.EC2InstanceMetadata.LogFilePath, configData.EC2InstanceMetadata.MetricServerPort,
+			configData.MailerClientCreds.APIKey, configData.MailerClientCreds.Domain, configData.MailerClientCreds.Email, topicArn)
+
+		return base64.StdEncoding.EncodeToString([]byte(envFile))
+	}).(pulumi.StringOutput)
+
+	// Use the ACM certificate validated through the hosted zone, or the certificate created by hand
+	var certificateArn pulumi.StringInput = pulumi.String(configData.ResourceParams.CertificateArn)
+	if configData.Dns.Certificate.Enabled {
+		certificate, err := dns.NewCertificate(ctx, "webapp-certificate", &dns.CertificateArgs{
+			Domain:                  configData.Dns.Domain,
+			SubjectAlternativeNames: configData.Dns.Certificate.SubjectAlternativeNames,
+			HostedZoneID:            configData.Dns.HostedZoneID,
 		})
+		if err != nil {
+			return err
+		}
 
-		// Export the subnet and route table IDs for later use
-		//ctx.Export("LoadBalancerIP", loadBalancer.DnsName)
-		//ctx.Export("securityGroupId", securityGroup.ID())
-		//ctx.Export("publicSubnetIDs", publicSubnetsStrs)
-		//ctx.Export("privateSubnetIDs", privateSubnetsStrs)
-		//ctx.Export("rdsEndpoint", rdsInstance.Endpoint.ToStringOutput())
-		//ctx.Export("privateRouteTableIDs", pulumi.ToStringArray(pulumi.Map(privateRouteTables, func(rt *ec2.RouteTable) pulumi.IDOutput { return rt.ID() })))
+		certificateArn = certificate.Arn
+	} else if configData.ResourceParams.CertificateArn == "" {
+		return errors.New(`{"status": 400, "msg": "Either resource_params.certificate_arn or dns.certificate.enabled is required for the HTTPS listener."}`)
+	}
+
+	appLoadBalancer, err := loadbalancer.NewLoadBalancer(ctx, "webapp-load-balancer", &loadbalancer.LoadBalancerArgs{
+		VpcID:           vpcNetwork.VpcID,
+		SubnetIDs:       vpcNetwork.PublicSubnetIDs,
+		SecurityGroupID: securityGroups.LoadBalancerSecurityGroupID,
+		Config:          configData.LoadBalancer,
+		CertificateArn:  certificateArn,
+		HTTPPort:        configData.InboundPorts["http"],
+		AppPort:         configData.InboundPorts["customPort"],
+		AccountID:       configData.ResourceParams.AccountID,
+		Region:          configData.ResourceParams.Region,
+		BlueGreen:       configData.BlueGreen,
+		Namer:           namer,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Put the WAF web ACL in front of the load balancer
+	if configData.WAF.Enabled {
+		webACL, err := waf.NewWebACL(ctx, "webapp-waf", &waf.WebACLArgs{
+			Config:          configData.WAF,
+			LoadBalancerArn: appLoadBalancer.Arn,
+			LogsKmsKey:      kmsKeys.Logs,
+			Namer:           namer,
+		})
+		if err != nil {
+			return err
+		}
 
-		return nil
+		ctx.Export("webAclArn", webACL.Arn)
+	}
+
+	appTier, err := apptier.NewAppTier(ctx, "webapp-app-tier", &apptier.AppTierArgs{
+		Instance:                  configData.EC2InstanceMetadata,
+		LogGroups:                 configData.LogGroups,
+		UserData:                  userData,
+		InstanceProfileName:       instanceRole.InstanceProfileName,
+		SecurityGroupID:           securityGroups.AppSecurityGroupID,
+		SubnetIDs:                 vpcNetwork.PublicSubnetIDs,
+		TargetGroupArns:           appLoadBalancer.TargetGroupArns,
+		GreenTargetGroupArn:       appLoadBalancer.GreenTargetGroupArn,
+		LoadBalancerArnSuffix:     appLoadBalancer.ArnSuffix,
+		TargetGroupArnSuffix:      appLoadBalancer.TargetGroupArnSuffix,
+		GreenTargetGroupArnSuffix: appLoadBalancer.GreenTargetGroupArnSuffix,
+		BlueGreen:                 configData.BlueGreen,
+		AutoScaling:               configData.AutoScaling,
+		EbsKmsKey:                 kmsKeys.EBS,
+		LogsKmsKey:                kmsKeys.Logs,
+		Namer:                     namer,
+	})
+	if err != nil {
+		return err
+	}
+
+	appRecord, err := dns.NewDns(ctx, "webapp-dns", &dns.DnsArgs{
+		RecordName:   configData.Dns.ARecordName,
+		Domain:       configData.Dns.Domain,
+		Type:         configData.Dns.Type,
+		HostedZoneID: configData.Dns.HostedZoneID,
+		AliasName:    appLoadBalancer.DnsName,
+		AliasZoneID:  appLoadBalancer.ZoneID,
 	})
+	if err != nil {
+		return err
+	}
+
+	// Export the stack outputs used by downstream stacks and deploy scripts
+	ctx.Export("vpcId", vpcNetwork.VpcID)
+	ctx.Export("publicSubnetIds", vpcNetwork.PublicSubnetIDs)
+	ctx.Export("privateSubnetIds", vpcNetwork.PrivateSubnetIDs)
+	ctx.Export("publicSubnetIdsByAz", vpcNetwork.PublicSubnetIDsByAz)
+	ctx.Export("privateSubnetIdsByAz", vpcNetwork.PrivateSubnetIDsByAz)
+	ctx.Export("loadBalancerSecurityGroupId", securityGroups.LoadBalancerSecurityGroupID)
+	ctx.Export("appSecurityGroupId", securityGroups.AppSecurityGroupID)
+	ctx.Export("databaseSecurityGroupId", securityGroups.DatabaseSecurityGroupID)
+	ctx.Export("albDnsName", appLoadBalancer.DnsName)
+	ctx.Export("albArn", appLoadBalancer.Arn)
+	ctx.Export("targetGroupArn", appLoadBalancer.TargetGroupArn)
+	ctx.Export("asgName", appTier.AutoScalingGroupName)
+	if configData.BlueGreen.Enabled {
+		ctx.Export("asgNames", appTier.AutoScalingGroupNames)
+		ctx.Export("greenTargetGroupArn", appLoadBalancer.GreenTargetGroupArn)
+		ctx.Export("liveColor", pulumi.String(configData.BlueGreen.LiveColor()))
+	}
+	ctx.Export("rdsEndpoint", db.WriterHost)
+	ctx.Export("rdsReaderEndpoints", db.ReaderHosts)
+	ctx.Export("rdsPort", pulumi.Int(configData.RDSInstanceMetadata.AllowsPort))
+	ctx.Export("snsTopicArn", topic.TopicArn)
+	ctx.Export("recordFqdn", appRecord.Fqdn)
+	if db.AlertTopic != nil {
+		ctx.Export("alertTopicArn", db.AlertTopic.Arn)
+	}
+
+	// Credentials are only exported as secrets so they are encrypted in state and masked in the CLI output
+	ctx.Export("rdsUsername", pulumi.ToSecret(pulumi.String(configData.RDSInstanceMetadata.Username)))
+	ctx.Export("rdsPassword", pulumi.ToSecret(pulumi.String(configData.RDSInstanceMetadata.Password)))
+	ctx.Export("rdsConnectionString", pulumi.ToSecret(pulumi.Sprintf("%s://%s:%s@%s:%d/%s",
+		configData.RDSInstanceMetadata.DbDriver, configData.RDSInstanceMetadata.Username, configData.RDSInstanceMetadata.Password,
+		db.WriterHost, configData.RDSInstanceMetadata.AllowsPort, configData.RDSInstanceMetadata.DbName)))
+
+	return nil
 }
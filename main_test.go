@@ -0,0 +1,1790 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/apptier"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/database"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/deployment"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/dns"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/encryption"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/loadbalancer"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/tagging"
+	"github.com/shivasaicharanruthala/iac-pulumi/components/waf"
+)
+
+const (
+	testRegion    = "us-east-1"
+	testAccountID = "123456789012"
+)
+
+var testAvailabilityZones = []string{"us-east-1a", "us-east-1b", "us-east-1c", "us-east-1d"}
+
+type mockResource struct {
+	Type          string
+	Name          string
+	Inputs        resource.PropertyMap
+	IgnoreChanges []string
+	Aliases       []*pulumirpc.Alias
+}
+
+// mocks fakes the resource monitor, it records every registered resource and returns its inputs as its state
+// together with the computed outputs the program reads (ARNs, endpoints, DNS names).
+type mocks struct {
+	mu        sync.Mutex
+	resources []mockResource
+}
+
+func (m *mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	m.mu.Lock()
+	m.resources = append(m.resources, mockResource{
+		Type:          args.TypeToken,
+		Name:          args.Name,
+		Inputs:        args.Inputs,
+		IgnoreChanges: args.RegisterRPC.GetIgnoreChanges(),
+		Aliases:       args.RegisterRPC.GetAliases(),
+	})
+	m.mu.Unlock()
+
+	outputs := args.Inputs.Copy()
+	if _, ok := outputs["name"]; !ok {
+		outputs["name"] = resource.NewStringProperty(args.Name)
+	}
+
+	if _, ok := outputs["arn"]; !ok {
+		service := strings.Split(args.TypeToken, ":")[1]
+		service = strings.Split(service, "/")[0]
+		outputs["arn"] = resource.NewStringProperty(fmt.Sprintf("arn:aws:%s:%s:%s:%s", service, testRegion, testAccountID, outputs["name"].StringValue()))
+	}
+
+	switch args.TypeToken {
+	case "aws:rds/instance:Instance":
+		outputs["address"] = resource.NewStringProperty(args.Name + ".rds.amazonaws.com")
+	case "aws:rds/cluster:Cluster":
+		outputs["endpoint"] = resource.NewStringProperty(args.Name + ".cluster.rds.amazonaws.com")
+		outputs["readerEndpoint"] = resource.NewStringProperty(args.Name + ".cluster-ro.rds.amazonaws.com")
+	case "aws:rds/proxy:Proxy":
+		outputs["endpoint"] = resource.NewStringProperty(args.Name + ".proxy.rds.amazonaws.com")
+	case "aws:rds/proxyEndpoint:ProxyEndpoint":
+		outputs["endpoint"] = resource.NewStringProperty(args.Name + ".endpoint.proxy.rds.amazonaws.com")
+	case "aws:lb/loadBalancer:LoadBalancer":
+		outputs["dnsName"] = resource.NewStringProperty(args.Name + ".elb.amazonaws.com")
+		outputs["zoneId"] = resource.NewStringProperty("Z35SXDOTRQ7X7K")
+		outputs["arnSuffix"] = resource.NewStringProperty("app/" + args.Name + "/50dc6c495c0c9188")
+	case "aws:lb/targetGroup:TargetGroup":
+		outputs["arnSuffix"] = resource.NewStringProperty("targetgroup/" + args.Name + "/73e2d6bc24d8a067")
+	case "aws:route53/record:Record":
+		outputs["fqdn"] = outputs["name"]
+	case "aws:ec2/launchTemplate:LaunchTemplate":
+		outputs["latestVersion"] = resource.NewNumberProperty(1)
+	case "aws:kms/key:Key":
+		outputs["keyId"] = resource.NewStringProperty(args.Name + "-key-id")
+	case "aws:acm/certificate:Certificate":
+		// ACM returns the validation options sorted by domain, not in the order they were requested
+		domains := []string{args.Inputs["domainName"].StringValue()}
+		for _, name := range arrayValue(args.Inputs, "subjectAlternativeNames") {
+			domains = append(domains, name.StringValue())
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(domains)))
+
+		var options []interface{}
+		for _, domain := range domains {
+			options = append(options, map[string]interface{}{
+				"domainName":          domain,
+				"resourceRecordName":  "_validation." + strings.TrimPrefix(domain, "*.") + ".",
+				"resourceRecordType":  "CNAME",
+				"resourceRecordValue": "_token." + strings.TrimPrefix(domain, "*.") + ".acm-validations.aws.",
+			})
+		}
+		outputs["domainValidationOptions"] = resource.NewPropertyValue(options)
+	}
+
+	return args.Name + "_id", outputs, nil
+}
+
+func (m *mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	if args.Token == "aws:index/getAvailabilityZones:getAvailabilityZones" {
+		return resource.NewPropertyMapFromMap(map[string]interface{}{
+			"names":   testAvailabilityZones,
+			"zoneIds": []string{"use1-az1", "use1-az2", "use1-az3", "use1-az4"},
+		}), nil
+	}
+
+	return args.Args, nil
+}
+
+func (m *mocks) byType(typeToken string) []mockResource {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var resources []mockResource
+	for _, res := range m.resources {
+		if res.Type == typeToken {
+			resources = append(resources, res)
+		}
+	}
+
+	return resources
+}
+
+func (m *mocks) find(t *testing.T, typeToken string, name string) mockResource {
+	t.Helper()
+
+	for _, res := range m.byType(typeToken) {
+		if res.Name == name {
+			return res
+		}
+	}
+
+	t.Fatalf("resource %s %q was not registered", typeToken, name)
+	return mockResource{}
+}
+
+// testConfig returns a config similar to Pulumi.dev.yaml that only talks to local fakes.
+func testConfig(t *testing.T, maxAvailabilityZones int) Data {
+	t.Helper()
+
+	ipServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"origin": "203.0.113.10"}`))
+	}))
+	t.Cleanup(ipServer.Close)
+
+	publicKeyFilePath := filepath.Join(t.TempDir(), "id_rsa.pub")
+	if err := os.WriteFile(publicKeyFilePath, []byte("ssh-rsa AAAAB3NzaC1yc2E test"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return Data{
+		Vpc:                       "webapp-vpc",
+		VpcCidar:                  "10.0.0.0/16",
+		VpcInstanceTenancy:        "default",
+		InternetGateway:           "webapp-igw",
+		InternetGatewayAttachment: "webapp-igw-attachment",
+		PublicRoute:               "webapp-public-route",
+		PublicRouteTable:          "webapp-public-route-table",
+		PrivateRouteTable:         "webapp-private-route-table",
+		PublicDestinationCidar:    "0.0.0.0/0",
+		BitsToMask:                24,
+		MaxAvailabilityZones:      maxAvailabilityZones,
+		PublicSubnetsPrefix:       "webapp-public-subnet",
+		PrivateSubnetPrefix:       "webapp-private-subnet",
+		SecurityGroup:             "application-security-group",
+		SecurityRuleProtocol:      "tcp",
+		InboundPorts: map[string]int{
+			"ssh":        22,
+			"https":      443,
+			"customPort": 8080,
+		},
+		FetchPublicIPURL: ipServer.URL,
+		EC2InstanceMetadata: apptier.EC2Instance{
+			InstanceName:      "webapp-instance",
+			InstanceType:      "t2.micro",
+			VolumeSize:        25,
+			VolumeType:        "gp2",
+			DeviceType:        "/dev/xvda",
+			AmiID:             "ami-0123456789abcdef0",
+			SSHKeyName:        "webapp-key",
+			LogFilePath:       "/var/log/webapp.log",
+			MetricServerPort:  8125,
+			PublicKeyFilePath: publicKeyFilePath,
+		},
+		Dns: DNS{
+			ARecordName:  "webapp-a-record",
+			Type:         "A",
+			Domain:       "dev.example.com",
+			HostedZoneID: "Z0123456789",
+		},
+		ResourceParams: Resource{
+			Region:         testRegion,
+			AccountID:      testAccountID,
+			SNSTopic:       "submissions",
+			CertificateArn: "arn:aws:acm:us-east-1:123456789012:certificate/test",
+		},
+		RDSInstanceMetadata: database.RDSInstance{
+			SubnetGrp:         "webapp-db-subnet-group",
+			SecurityGroupName: "database-security-group",
+			AllowsPort:        5432,
+			Protocol:          "tcp",
+			InstanceName:      "webapp-db",
+			Engine:            "postgres",
+			EngineVersion:     "15.4",
+			InstanceClass:     "db.t3.micro",
+			AllowedStorage:    20,
+			Identifier:        "webapp-db",
+			Username:          "webapp",
+			Password:          "s3cr3t-password",
+			DbName:            "webapp",
+			DbDriver:          "postgres",
+			SkipFinalSnapShot: true,
+		},
+		MailerClientCreds: MailerClient{
+			APIKey: "mailgun-key",
+			Domain: "mg.example.com",
+			Email:  "noreply@example.com",
+		},
+		Tags: tagging.Tags{
+			Environment: "test",
+			Owner:       "platform-team",
+			CostCenter:  "cc-1234",
+			Extra:       map[string]string{"team": "webapp"},
+		},
+		PublicRouteTableSubnetsAssociationPrefix:  "webapp-public-rta",
+		PrivateRouteTableSubnetsAssociationPrefix: "webapp-private-rta",
+	}
+}
+
+func runWithMocks(t *testing.T, configData Data) (*mocks, error) {
+	t.Helper()
+
+	m := &mocks{}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		return createInfrastructure(ctx, configData)
+	}, pulumi.WithMocks("iac-pulumi", "test", m))
+
+	return m, err
+}
+
+func stringInput(res mockResource, key string) string {
+	value, ok := res.Inputs[resource.PropertyKey(key)]
+	if !ok {
+		return ""
+	}
+
+	if value.IsSecret() {
+		value = value.SecretValue().Element
+	}
+
+	if !value.IsString() {
+		return ""
+	}
+
+	return value.StringValue()
+}
+
+func arrayValue(properties resource.PropertyMap, key string) []resource.PropertyValue {
+	value, ok := properties[resource.PropertyKey(key)]
+	if !ok || !value.IsArray() {
+		return nil
+	}
+
+	return value.ArrayValue()
+}
+
+func tagValue(res mockResource, key string) string {
+	tags, ok := res.Inputs["tags"]
+	if !ok || !tags.IsObject() {
+		return ""
+	}
+
+	value, ok := tags.ObjectValue()[resource.PropertyKey(key)]
+	if !ok {
+		return ""
+	}
+
+	return value.StringValue()
+}
+
+func TestSubnetsPerAvailabilityZone(t *testing.T) {
+	tests := []struct {
+		name                 string
+		maxAvailabilityZones int
+		bitsToMask           int
+		publicCidrs          []string
+		privateCidrs         []string
+	}{
+		{
+			name:                 "two availability zones",
+			maxAvailabilityZones: 2,
+			bitsToMask:           24,
+			publicCidrs:          []string{"10.0.0.0/24", "10.0.1.0/24"},
+			privateCidrs:         []string{"10.0.2.0/24", "10.0.3.0/24"},
+		},
+		{
+			name:                 "three availability zones",
+			maxAvailabilityZones: 3,
+			bitsToMask:           24,
+			publicCidrs:          []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"},
+			privateCidrs:         []string{"10.0.3.0/24", "10.0.4.0/24", "10.0.5.0/24"},
+		},
+		{
+			name:                 "four availability zones with smaller subnets",
+			maxAvailabilityZones: 4,
+			bitsToMask:           20,
+			publicCidrs:          []string{"10.0.0.0/20", "10.0.16.0/20", "10.0.32.0/20", "10.0.48.0/20"},
+			privateCidrs:         []string{"10.0.64.0/20", "10.0.80.0/20", "10.0.96.0/20", "10.0.112.0/20"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := testConfig(t, tt.maxAvailabilityZones)
+			configData.BitsToMask = tt.bitsToMask
+
+			m, err := runWithMocks(t, configData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := len(m.byType("aws:ec2/subnet:Subnet")); got != 2*tt.maxAvailabilityZones {
+				t.Errorf("got %d subnets, want %d", got, 2*tt.maxAvailabilityZones)
+			}
+
+			if got := len(m.byType("aws:ec2/routeTableAssociation:RouteTableAssociation")); got != 2*tt.maxAvailabilityZones {
+				t.Errorf("got %d route table associations, want %d", got, 2*tt.maxAvailabilityZones)
+			}
+
+			if got := len(m.byType("aws:ec2/routeTable:RouteTable")); got != 2 {
+				t.Errorf("got %d route tables, want 2", got)
+			}
+
+			for i := 0; i < tt.maxAvailabilityZones; i++ {
+				for prefix, cidrs := range map[string][]string{configData.PublicSubnetsPrefix: tt.publicCidrs, configData.PrivateSubnetPrefix: tt.privateCidrs} {
+					subnet := m.find(t, "aws:ec2/subnet:Subnet", fmt.Sprintf("%s-%d", prefix, i))
+
+					if got := stringInput(subnet, "cidrBlock"); got != cidrs[i] {
+						t.Errorf("%s cidr = %s, want %s", subnet.Name, got, cidrs[i])
+					}
+
+					if got := stringInput(subnet, "availabilityZone"); got != testAvailabilityZones[i] {
+						t.Errorf("%s availability zone = %s, want %s", subnet.Name, got, testAvailabilityZones[i])
+					}
+
+					if got := stringInput(subnet, "vpcId"); got != "webapp-vpc_id" {
+						t.Errorf("%s vpc = %s, want webapp-vpc_id", subnet.Name, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidNetworkConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(configData *Data)
+		wantErr string
+	}{
+		{
+			name:    "more availability zones than the region has",
+			mutate:  func(configData *Data) { configData.MaxAvailabilityZones = len(testAvailabilityZones) + 1 },
+			wantErr: "Not sufficient AvailabilityZones",
+		},
+		{
+			name:    "no availability zones",
+			mutate:  func(configData *Data) { configData.MaxAvailabilityZones = 0 },
+			wantErr: "Not sufficient AvailabilityZones",
+		},
+		{
+			name:    "bits to mask out of range",
+			mutate:  func(configData *Data) { configData.BitsToMask = 33 },
+			wantErr: "Incorrect param bits_to_mask",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := testConfig(t, 2)
+			tt.mutate(&configData)
+
+			_, err := runWithMocks(t, configData)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecurityGroupRules(t *testing.T) {
+	configData := testConfig(t, 2)
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type rule struct {
+		port           float64
+		cidrs          []string
+		securityGroups []string
+	}
+
+	rules := func(res mockResource, key string) []rule {
+		var got []rule
+		for _, value := range arrayValue(res.Inputs, key) {
+			object := value.ObjectValue()
+			r := rule{port: object["fromPort"].NumberValue()}
+			if object["toPort"].NumberValue() != r.port {
+				t.Errorf("%s %s rule has port range %v-%v, want a single port", res.Name, key, r.port, object["toPort"].NumberValue())
+			}
+
+			for _, cidr := range arrayValue(object, "cidrBlocks") {
+				r.cidrs = append(r.cidrs, cidr.StringValue())
+			}
+
+			for _, securityGroup := range arrayValue(object, "securityGroups") {
+				r.securityGroups = append(r.securityGroups, securityGroup.StringValue())
+			}
+
+			got = append(got, r)
+		}
+
+		return got
+	}
+
+	loadBalancerSecurityGroup := m.find(t, "aws:ec2/securityGroup:SecurityGroup", "load-balancer-security-group")
+	if got := rules(loadBalancerSecurityGroup, "ingress"); len(got) != 1 || got[0].port != 443 || len(got[0].cidrs) != 1 || got[0].cidrs[0] != "0.0.0.0/0" {
+		t.Errorf("load balancer ingress = %+v, want only https from anywhere", got)
+	}
+
+	appSecurityGroup := m.find(t, "aws:ec2/securityGroup:SecurityGroup", configData.SecurityGroup)
+	for _, r := range rules(appSecurityGroup, "ingress") {
+		if len(r.cidrs) != 0 || len(r.securityGroups) != 1 || r.securityGroups[0] != "load-balancer-security-group_id" {
+			t.Errorf("app ingress on port %v = %+v, want only the load balancer security group", r.port, r)
+		}
+	}
+
+	databaseSecurityGroup := m.find(t, "aws:ec2/securityGroup:SecurityGroup", configData.RDSInstanceMetadata.SecurityGroupName)
+	if got := rules(databaseSecurityGroup, "ingress"); len(got) != 1 || got[0].port != 5432 || len(got[0].securityGroups) != 1 || got[0].securityGroups[0] != configData.SecurityGroup+"_id" {
+		t.Errorf("database ingress = %+v, want only postgres from the app security group", got)
+	}
+
+	if got := rules(databaseSecurityGroup, "egress"); len(got) != 0 {
+		t.Errorf("database egress = %+v, want none", got)
+	}
+
+	outboundToDB := m.find(t, "aws:ec2/securityGroupRule:SecurityGroupRule", "AllowOutboundToDB")
+	if stringInput(outboundToDB, "type") != "egress" || stringInput(outboundToDB, "securityGroupId") != configData.SecurityGroup+"_id" ||
+		stringInput(outboundToDB, "sourceSecurityGroupId") != configData.RDSInstanceMetadata.SecurityGroupName+"_id" {
+		t.Errorf("AllowOutboundToDB = %v, want app to database egress", outboundToDB.Inputs)
+	}
+}
+
+func TestRDSProxySecurityGroups(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.RDSInstanceMetadata.Proxy = database.RDSProxy{Enabled: true}
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The database security group has inline rules, the proxy is added to them instead of a separate rule
+	type ingressRule struct {
+		fromPort float64
+		toPort   float64
+		protocol string
+		sources  []string
+	}
+
+	var ingress []ingressRule
+	for _, value := range arrayValue(m.find(t, "aws:ec2/securityGroup:SecurityGroup", configData.RDSInstanceMetadata.SecurityGroupName).Inputs, "ingress") {
+		object := value.ObjectValue()
+		rule := ingressRule{fromPort: object["fromPort"].NumberValue(), toPort: object["toPort"].NumberValue(), protocol: object["protocol"].StringValue()}
+		for _, securityGroup := range arrayValue(object, "securityGroups") {
+			rule.sources = append(rule.sources, securityGroup.StringValue())
+		}
+
+		ingress = append(ingress, rule)
+	}
+
+	want := []ingressRule{
+		{fromPort: 5432, toPort: 5432, protocol: "tcp", sources: []string{configData.SecurityGroup + "_id"}},
+		{fromPort: 5432, toPort: 5432, protocol: "tcp", sources: []string{"rds-proxy-security-group_id"}},
+	}
+	if !reflect.DeepEqual(ingress, want) {
+		t.Errorf("database ingress = %+v, want %+v", ingress, want)
+	}
+
+	for _, name := range []string{"AllowInboundFromProxy", "AllowOutboundToProxy"} {
+		for _, rule := range m.byType("aws:ec2/securityGroupRule:SecurityGroupRule") {
+			if rule.Name == name {
+				t.Errorf("separate security group rule %s was created", name)
+			}
+		}
+	}
+
+	proxySecurityGroup := m.find(t, "aws:ec2/securityGroup:SecurityGroup", "rds-proxy-security-group")
+
+	// The proxy security group keeps the URN it had under the database component
+	databaseURN := "urn:pulumi:test::iac-pulumi::webapp:database:Database::webapp-database"
+	if len(proxySecurityGroup.Aliases) != 1 || proxySecurityGroup.Aliases[0].GetSpec().GetParentUrn() != databaseURN {
+		t.Errorf("proxy security group aliases = %v, want the database component %s as previous parent", proxySecurityGroup.Aliases, databaseURN)
+	}
+	egress := arrayValue(proxySecurityGroup.Inputs, "egress")
+	if len(egress) != 1 || arrayValue(egress[0].ObjectValue(), "cidrBlocks")[0].StringValue() != configData.VpcCidar {
+		t.Errorf("proxy egress = %v, want the database port inside %s", egress, configData.VpcCidar)
+	}
+
+	proxy := m.find(t, "aws:rds/proxy:Proxy", "webapp-db-proxy")
+	if groups := arrayValue(proxy.Inputs, "vpcSecurityGroupIds"); len(groups) != 1 || groups[0].StringValue() != "rds-proxy-security-group_id" {
+		t.Errorf("proxy security groups = %v, want rds-proxy-security-group_id", groups)
+	}
+}
+
+func TestRDSProxy(t *testing.T) {
+	tests := []struct {
+		name            string
+		mutate          func(metadata *database.RDSInstance)
+		wantTarget      string
+		wantTargetValue string
+		wantReader      bool
+	}{
+		{
+			name:            "instance",
+			mutate:          func(metadata *database.RDSInstance) {},
+			wantTarget:      "dbInstanceIdentifier",
+			wantTargetValue: "webapp-db",
+		},
+		{
+			name: "aurora cluster with readers",
+			mutate: func(metadata *database.RDSInstance) {
+				metadata.Mode = database.RDSModeAurora
+				metadata.Aurora = database.AuroraCluster{ReaderCount: 1}
+			},
+			wantTarget:      "dbClusterIdentifier",
+			wantTargetValue: "webapp-db-cluster",
+			wantReader:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := testConfig(t, 2)
+			configData.RDSInstanceMetadata.Proxy = database.RDSProxy{Enabled: true}
+			tt.mutate(&configData.RDSInstanceMetadata)
+
+			m, err := runWithMocks(t, configData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proxy := m.find(t, "aws:rds/proxy:Proxy", "webapp-db-proxy")
+			if stringInput(proxy, "engineFamily") != "POSTGRESQL" || !proxy.Inputs["requireTls"].BoolValue() || proxy.Inputs["idleClientTimeout"].NumberValue() != 1800 {
+				t.Errorf("proxy = %v, want a POSTGRESQL proxy requiring TLS with the default idle timeout", proxy.Inputs)
+			}
+
+			auths := arrayValue(proxy.Inputs, "auths")
+			if len(auths) != 1 || auths[0].ObjectValue()["authScheme"].StringValue() != "SECRETS" {
+				t.Errorf("proxy auths = %v, want the credentials secret", auths)
+			}
+
+			targetGroup := m.find(t, "aws:rds/proxyDefaultTargetGroup:ProxyDefaultTargetGroup", "webapp-db-proxy-target-group")
+			if pool := targetGroup.Inputs["connectionPoolConfig"].ObjectValue(); pool["maxConnectionsPercent"].NumberValue() != 90 {
+				t.Errorf("proxy connection pool = %v, want 90 max connections percent", pool)
+			}
+
+			target := m.find(t, "aws:rds/proxyTarget:ProxyTarget", "webapp-db-proxy-target")
+			if got := stringInput(target, tt.wantTarget); got != tt.wantTargetValue {
+				t.Errorf("proxy target %s = %q, want %q", tt.wantTarget, got, tt.wantTargetValue)
+			}
+
+			if readers := m.byType("aws:rds/proxyEndpoint:ProxyEndpoint"); (len(readers) == 1) != tt.wantReader {
+				t.Errorf("got %d proxy reader endpoints, want reader %t", len(readers), tt.wantReader)
+			}
+		})
+	}
+}
+
+func TestInvalidRDSProxy(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(metadata *database.RDSInstance)
+		wantErr string
+	}{
+		{
+			name: "unsupported engine",
+			mutate: func(metadata *database.RDSInstance) {
+				metadata.Engine, metadata.ParameterGroupFamily = "oracle-ee", "oracle-ee-19"
+			},
+			wantErr: "not supported by RDS Proxy",
+		},
+		{
+			name:    "max connections over 100 percent",
+			mutate:  func(metadata *database.RDSInstance) { metadata.Proxy.MaxConnectionsPercent = 101 },
+			wantErr: "proxy.max_connections_percent 101",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := testConfig(t, 2)
+			configData.RDSInstanceMetadata.Proxy = database.RDSProxy{Enabled: true}
+			tt.mutate(&configData.RDSInstanceMetadata)
+
+			_, err := runWithMocks(t, configData)
+			if err == nil || !strings.Contains(err.Error(), `"status": 400`) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFinalSnapshotIdentifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		skip       bool
+		configured string
+		want       string
+	}{
+		{name: "skipped", skip: true},
+		// Stacks created without an identifier hold an empty one in state, the default has to be applied as an update
+		{name: "existing stack without an identifier", want: "webapp-db-test-final"},
+		{name: "configured", configured: "webapp-db-before-migration", want: "webapp-db-before-migration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Previews must not show a diff between runs, the identifier is the same every time
+			for run := 0; run < 2; run++ {
+				configData := testConfig(t, 2)
+				configData.RDSInstanceMetadata.SkipFinalSnapShot = tt.skip
+				configData.RDSInstanceMetadata.FinalSnapshotIdentifier = tt.configured
+
+				m, err := runWithMocks(t, configData)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				instance := m.find(t, "aws:rds/instance:Instance", configData.RDSInstanceMetadata.InstanceName)
+				if got := stringInput(instance, "finalSnapshotIdentifier"); got != tt.want {
+					t.Errorf("run %d: finalSnapshotIdentifier = %q, want %q", run, got, tt.want)
+				}
+
+				if len(instance.IgnoreChanges) != 0 {
+					t.Errorf("run %d: instance ignores changes to %v", run, instance.IgnoreChanges)
+				}
+			}
+		})
+	}
+}
+
+func TestCrossRegionReadReplicas(t *testing.T) {
+	tests := []struct {
+		name      string
+		encrypted bool
+		replica   database.RDSReadReplica
+		wantErr   string
+	}{
+		{name: "same region", encrypted: true, replica: database.RDSReadReplica{Region: testRegion}},
+		{name: "subnet group and key of the region", encrypted: true, replica: database.RDSReadReplica{Region: "us-west-2", SubnetGrp: "webapp-db-subnet-group-usw2", KmsKeyID: "arn:aws:kms:us-west-2:123456789012:key/replica"}},
+		{name: "unencrypted primary without key", replica: database.RDSReadReplica{Region: "us-west-2", SubnetGrp: "webapp-db-subnet-group-usw2"}},
+		{name: "no subnet group", encrypted: true, replica: database.RDSReadReplica{Region: "us-west-2", KmsKeyID: "arn:aws:kms:us-west-2:123456789012:key/replica"}, wantErr: "needs a subnet_group"},
+		{name: "no kms key", encrypted: true, replica: database.RDSReadReplica{Region: "us-west-2", SubnetGrp: "webapp-db-subnet-group-usw2"}, wantErr: "needs a kms_key_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := testConfig(t, 2)
+			configData.RDSInstanceMetadata.StorageEncrypted = tt.encrypted
+			configData.RDSInstanceMetadata.ReadReplicas = []database.RDSReadReplica{tt.replica}
+
+			m, err := runWithMocks(t, configData)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), `"status": 400`) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			replica := m.find(t, "aws:rds/instance:Instance", configData.RDSInstanceMetadata.InstanceName+"-replica-0")
+			if got := stringInput(replica, "dbSubnetGroupName"); tt.replica.SubnetGrp != "" && got != tt.replica.SubnetGrp {
+				t.Errorf("replica subnet group = %q, want %q", got, tt.replica.SubnetGrp)
+			}
+
+			if got := stringInput(replica, "kmsKeyId"); got != tt.replica.KmsKeyID {
+				t.Errorf("replica kms key = %q, want %q", got, tt.replica.KmsKeyID)
+			}
+		})
+	}
+}
+
+func TestAuroraCluster(t *testing.T) {
+	tests := []struct {
+		name          string
+		aurora        database.AuroraCluster
+		wantClass     string
+		wantScaling   bool
+		wantInstances []string
+	}{
+		{
+			name:          "provisioned with a reader",
+			aurora:        database.AuroraCluster{ReaderCount: 1, InstanceClass: "db.r6g.large"},
+			wantClass:     "db.r6g.large",
+			wantInstances: []string{"webapp-db-cluster-writer", "webapp-db-cluster-reader-1"},
+		},
+		{
+			name:          "serverless writer only",
+			aurora:        database.AuroraCluster{Serverless: true, MinCapacity: 0.5, MaxCapacity: 4},
+			wantClass:     "db.serverless",
+			wantScaling:   true,
+			wantInstances: []string{"webapp-db-cluster-writer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := testConfig(t, 2)
+			configData.RDSInstanceMetadata.Mode = database.RDSModeAurora
+			configData.RDSInstanceMetadata.Aurora = tt.aurora
+
+			m, err := runWithMocks(t, configData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if instances := m.byType("aws:rds/instance:Instance"); len(instances) != 0 {
+				t.Errorf("got %d RDS instances in aurora mode, want none", len(instances))
+			}
+
+			parameterGroup := m.find(t, "aws:rds/clusterParameterGroup:ClusterParameterGroup", "webapp-cluster-parameter-group")
+			if family := stringInput(parameterGroup, "family"); family != "aurora-postgresql15" {
+				t.Errorf("cluster parameter group family = %q, want aurora-postgresql15", family)
+			}
+
+			cluster := m.find(t, "aws:rds/cluster:Cluster", "webapp-db-cluster")
+			if engine := stringInput(cluster, "engine"); engine != database.AuroraPostgresEngine {
+				t.Errorf("cluster engine = %q, want %s", engine, database.AuroraPostgresEngine)
+			}
+
+			if stringInput(cluster, "dbClusterParameterGroupName") != "webapp-aurora-aurora-postgresql15" {
+				t.Errorf("cluster parameter group = %q, want webapp-aurora-aurora-postgresql15", stringInput(cluster, "dbClusterParameterGroupName"))
+			}
+
+			if _, ok := cluster.Inputs["serverlessv2ScalingConfiguration"]; ok != tt.wantScaling {
+				t.Errorf("cluster has serverless v2 scaling %t, want %t", ok, tt.wantScaling)
+			}
+
+			var instances []string
+			for i, instance := range m.byType("aws:rds/clusterInstance:ClusterInstance") {
+				instances = append(instances, instance.Name)
+
+				if class := stringInput(instance, "instanceClass"); class != tt.wantClass {
+					t.Errorf("%s instance class = %q, want %q", instance.Name, class, tt.wantClass)
+				}
+
+				if tier := instance.Inputs["promotionTier"].NumberValue(); tier != float64(i) {
+					t.Errorf("%s promotion tier = %v, want %d", instance.Name, tier, i)
+				}
+			}
+
+			if !reflect.DeepEqual(instances, tt.wantInstances) {
+				t.Errorf("cluster instances = %v, want %v", instances, tt.wantInstances)
+			}
+
+			// Aurora publishes the alarm metrics per cluster
+			alarm := m.find(t, "aws:cloudwatch/metricAlarm:MetricAlarm", "rds-cpu-high")
+			if dimensions := alarm.Inputs["dimensions"].ObjectValue(); dimensions["DBClusterIdentifier"].StringValue() != "webapp-db-cluster" {
+				t.Errorf("cpu alarm dimensions = %v, want the cluster", dimensions)
+			}
+		})
+	}
+}
+
+func TestInvalidAuroraCluster(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(metadata *database.RDSInstance)
+		wantErr string
+	}{
+		{
+			name:    "read replicas",
+			mutate:  func(metadata *database.RDSInstance) { metadata.ReadReplicas = []database.RDSReadReplica{{}} },
+			wantErr: "use aurora.reader_count instead",
+		},
+		{
+			name:    "negative reader count",
+			mutate:  func(metadata *database.RDSInstance) { metadata.Aurora.ReaderCount = -1 },
+			wantErr: "aurora.reader_count -1",
+		},
+		{
+			name: "serverless capacity out of order",
+			mutate: func(metadata *database.RDSInstance) {
+				metadata.Aurora = database.AuroraCluster{Serverless: true, MinCapacity: 8, MaxCapacity: 4}
+			},
+			wantErr: "Serverless v2 capacity",
+		},
+		{
+			name: "backups disabled",
+			mutate: func(metadata *database.RDSInstance) {
+				backupRetentionPeriod := 0
+				metadata.BackupRetentionPeriod = &backupRetentionPeriod
+			},
+			wantErr: "Backups can't be disabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := testConfig(t, 2)
+			configData.RDSInstanceMetadata.Mode = database.RDSModeAurora
+			tt.mutate(&configData.RDSInstanceMetadata)
+
+			_, err := runWithMocks(t, configData)
+			if err == nil || !strings.Contains(err.Error(), `"status": 400`) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPRedirect(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			configData := testConfig(t, 2)
+			configData.LoadBalancer.HTTPRedirect = enabled
+
+			m, err := runWithMocks(t, configData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ingressPorts []float64
+			for _, value := range arrayValue(m.find(t, "aws:ec2/securityGroup:SecurityGroup", "load-balancer-security-group").Inputs, "ingress") {
+				ingressPorts = append(ingressPorts, value.ObjectValue()["fromPort"].NumberValue())
+			}
+
+			var listeners []mockResource
+			for _, listener := range m.byType("aws:lb/listener:Listener") {
+				if listener.Name == "httpRedirectListener" {
+					listeners = append(listeners, listener)
+				}
+			}
+
+			if !enabled {
+				if len(listeners) != 0 || len(ingressPorts) != 1 {
+					t.Errorf("got %d redirect listeners and ingress ports %v, want none and only https", len(listeners), ingressPorts)
+				}
+
+				return
+			}
+
+			if len(ingressPorts) != 2 || ingressPorts[1] != 80 {
+				t.Errorf("load balancer ingress ports = %v, want https and http", ingressPorts)
+			}
+
+			if len(listeners) != 1 {
+				t.Fatalf("got %d redirect listeners, want 1", len(listeners))
+			}
+
+			listener := listeners[0]
+			if port := listener.Inputs["port"].NumberValue(); port != 80 || stringInput(listener, "protocol") != "HTTP" {
+				t.Errorf("redirect listener on %v %s, want HTTP 80", port, stringInput(listener, "protocol"))
+			}
+
+			actions := arrayValue(listener.Inputs, "defaultActions")
+			if len(actions) != 1 {
+				t.Fatalf("got %d default actions, want 1", len(actions))
+			}
+
+			redirect := actions[0].ObjectValue()["redirect"].ObjectValue()
+			if redirect["statusCode"].StringValue() != "HTTP_301" || redirect["protocol"].StringValue() != "HTTPS" || redirect["port"].StringValue() != "443" {
+				t.Errorf("redirect = %v, want a 301 to HTTPS 443", redirect)
+			}
+		})
+	}
+}
+
+func TestCertificate(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.ResourceParams.CertificateArn = ""
+	configData.Dns.Certificate = dns.Certificate{
+		Enabled:                 true,
+		SubjectAlternativeNames: []string{"www.dev.example.com", "*.dev.example.com"},
+	}
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate := m.find(t, "aws:acm/certificate:Certificate", "app-certificate")
+	if stringInput(certificate, "domainName") != configData.Dns.Domain || stringInput(certificate, "validationMethod") != "DNS" {
+		t.Errorf("certificate for %s validated by %s, want %s validated by DNS", stringInput(certificate, "domainName"),
+			stringInput(certificate, "validationMethod"), configData.Dns.Domain)
+	}
+
+	// The wildcard shares the validation record of the domain it covers
+	wantRecords := map[string]string{
+		"app-certificate-validation-0": "_validation.dev.example.com.",
+		"app-certificate-validation-1": "_validation.www.dev.example.com.",
+	}
+
+	var gotRecords []string
+	for _, record := range m.byType("aws:route53/record:Record") {
+		if want, ok := wantRecords[record.Name]; ok {
+			gotRecords = append(gotRecords, record.Name)
+			if got := stringInput(record, "name"); got != want || stringInput(record, "zoneId") != configData.Dns.HostedZoneID {
+				t.Errorf("%s = %s in zone %s, want %s in zone %s", record.Name, got, stringInput(record, "zoneId"), want, configData.Dns.HostedZoneID)
+			}
+		}
+	}
+
+	if len(gotRecords) != len(wantRecords) {
+		t.Errorf("got validation records %v, want %d", gotRecords, len(wantRecords))
+	}
+
+	validation := m.find(t, "aws:acm/certificateValidation:CertificateValidation", "app-certificate-validation")
+	if got := len(arrayValue(validation.Inputs, "validationRecordFqdns")); got != len(wantRecords) {
+		t.Errorf("certificate validation waits on %d records, want %d", got, len(wantRecords))
+	}
+
+	wantArn := fmt.Sprintf("arn:aws:acm:%s:%s:app-certificate", testRegion, testAccountID)
+	if got := stringInput(m.find(t, "aws:lb/listener:Listener", "frontEndListener"), "certificateArn"); got != wantArn {
+		t.Errorf("https listener certificate = %q, want the validated certificate %q", got, wantArn)
+	}
+}
+
+func TestMissingCertificate(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.ResourceParams.CertificateArn = ""
+
+	if _, err := runWithMocks(t, configData); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("got error %v, want the missing certificate", err)
+	}
+}
+
+func TestListenerRules(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.LoadBalancer.TargetGroups = []loadbalancer.TargetGroup{
+		{Name: "api", Port: 9090, TargetGroupSettings: loadbalancer.TargetGroupSettings{HealthCheck: loadbalancer.HealthCheck{Path: "/api/healthz"}}},
+		{Name: "admin", Port: 8080},
+	}
+	configData.LoadBalancer.ListenerRules = []loadbalancer.ListenerRule{
+		{
+			Name:       "api",
+			Priority:   10,
+			Conditions: loadbalancer.RuleConditions{HostHeaders: []string{"api.dev.example.com"}, PathPatterns: []string{"/api/*"}},
+			Action:     loadbalancer.RuleAction{Type: loadbalancer.RuleActionForward, TargetGroup: "api"},
+		},
+		{
+			Name:     "admin",
+			Priority: 20,
+			Conditions: loadbalancer.RuleConditions{
+				PathPatterns: []string{"/admin/*"},
+				HTTPHeaders:  []loadbalancer.HTTPHeaderCondition{{Name: "X-Admin", Values: []string{"true"}}},
+				QueryStrings: []loadbalancer.QueryStringCondition{{Key: "debug", Value: "1"}, {Value: "preview"}},
+			},
+			Action: loadbalancer.RuleAction{Type: loadbalancer.RuleActionForward, TargetGroup: "admin"},
+		},
+		{
+			Name:       "maintenance",
+			Priority:   30,
+			Conditions: loadbalancer.RuleConditions{PathPatterns: []string{"/maintenance"}},
+			Action: loadbalancer.RuleAction{Type: loadbalancer.RuleActionFixedResponse, FixedResponse: &loadbalancer.FixedResponseAction{
+				StatusCode:  503,
+				ContentType: "text/html",
+				MessageBody: "<h1>Down for maintenance</h1>",
+			}},
+		},
+	}
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpsListenerArn := fmt.Sprintf("arn:aws:lb:%s:%s:frontEndListener", testRegion, testAccountID)
+	apiTargetGroupArn := fmt.Sprintf("arn:aws:lb:%s:%s:iac-pulumi-test-use1-api", testRegion, testAccountID)
+
+	api := m.find(t, "aws:lb/listenerRule:ListenerRule", "listener-rule-api")
+	if stringInput(api, "listenerArn") != httpsListenerArn || api.Inputs["priority"].NumberValue() != 10 {
+		t.Errorf("api rule on %s with priority %v, want the https listener with priority 10", stringInput(api, "listenerArn"), api.Inputs["priority"])
+	}
+
+	if got := len(arrayValue(api.Inputs, "conditions")); got != 2 {
+		t.Errorf("api rule has %d conditions, want host header and path pattern", got)
+	}
+
+	if action := arrayValue(api.Inputs, "actions")[0].ObjectValue(); action["targetGroupArn"].StringValue() != apiTargetGroupArn {
+		t.Errorf("api rule forwards to %v, want %s", action["targetGroupArn"], apiTargetGroupArn)
+	}
+
+	// Every header is its own condition, query strings share one
+	if got := len(arrayValue(m.find(t, "aws:lb/listenerRule:ListenerRule", "listener-rule-admin").Inputs, "conditions")); got != 3 {
+		t.Errorf("admin rule has %d conditions, want path pattern, header and query string", got)
+	}
+
+	maintenance := arrayValue(m.find(t, "aws:lb/listenerRule:ListenerRule", "listener-rule-maintenance").Inputs, "actions")[0].ObjectValue()
+	fixedResponse := maintenance["fixedResponse"].ObjectValue()
+	if maintenance["type"].StringValue() != "fixed-response" || fixedResponse["statusCode"].StringValue() != "503" || fixedResponse["contentType"].StringValue() != "text/html" {
+		t.Errorf("maintenance action = %v, want a fixed 503 html response", maintenance)
+	}
+
+	apiTargetGroup := m.find(t, "aws:lb/targetGroup:TargetGroup", "api-target-group")
+	if apiTargetGroup.Inputs["port"].NumberValue() != 9090 || apiTargetGroup.Inputs["healthCheck"].ObjectValue()["path"].StringValue() != "/api/healthz" {
+		t.Errorf("api target group = %v, want port 9090 checked on /api/healthz", apiTargetGroup.Inputs)
+	}
+
+	// The app instances serve every target group
+	if got := len(arrayValue(m.find(t, "aws:autoscaling/group:Group", "example_auto_scaling_group").Inputs, "targetGroupArns")); got != 3 {
+		t.Errorf("auto scaling group is attached to %d target groups, want 3", got)
+	}
+
+	// Only the new port is opened, the admin target group shares the app port
+	var appIngressPorts []float64
+	for _, value := range arrayValue(m.find(t, "aws:ec2/securityGroup:SecurityGroup", configData.SecurityGroup).Inputs, "ingress") {
+		appIngressPorts = append(appIngressPorts, value.ObjectValue()["fromPort"].NumberValue())
+	}
+
+	if len(appIngressPorts) != 3 || appIngressPorts[2] != 9090 {
+		t.Errorf("app ingress ports = %v, want the app, ssh and api ports", appIngressPorts)
+	}
+}
+
+func TestTargetGroupSettings(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.InboundPorts["customPort"] = 3000
+	deregistrationDelay := 30
+	configData.LoadBalancer.TargetGroup = loadbalancer.TargetGroupSettings{
+		HealthCheck:         loadbalancer.HealthCheck{Path: "/ready", Matcher: "200-299", HealthyThreshold: 3, Timeout: 5, Interval: 10},
+		DeregistrationDelay: &deregistrationDelay,
+		SlowStart:           60,
+		Stickiness:          loadbalancer.Stickiness{Enabled: true, CookieDuration: 3600},
+	}
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targetGroup := m.find(t, "aws:lb/targetGroup:TargetGroup", "test")
+	if port := targetGroup.Inputs["port"].NumberValue(); port != 3000 {
+		t.Errorf("app target group port = %v, want the custom port 3000", port)
+	}
+
+	healthCheck := targetGroup.Inputs["healthCheck"].ObjectValue()
+	if healthCheck["path"].StringValue() != "/ready" || healthCheck["matcher"].StringValue() != "200-299" || healthCheck["healthyThreshold"].NumberValue() != 3 ||
+		healthCheck["unhealthyThreshold"].NumberValue() != 2 || healthCheck["timeout"].NumberValue() != 5 || healthCheck["interval"].NumberValue() != 10 {
+		t.Errorf("app health check = %v, want the configured settings with the default unhealthy threshold", healthCheck)
+	}
+
+	if targetGroup.Inputs["deregistrationDelay"].NumberValue() != 30 || targetGroup.Inputs["slowStart"].NumberValue() != 60 {
+		t.Errorf("deregistration delay %v and slow start %v, want 30 and 60", targetGroup.Inputs["deregistrationDelay"], targetGroup.Inputs["slowStart"])
+	}
+
+	stickiness := targetGroup.Inputs["stickiness"].ObjectValue()
+	if stickiness["type"].StringValue() != "lb_cookie" || stickiness["cookieDuration"].NumberValue() != 3600 {
+		t.Errorf("stickiness = %v, want an lb cookie for an hour", stickiness)
+	}
+
+	// The load balancer reaches the app on its port
+	egress := arrayValue(m.find(t, "aws:ec2/securityGroup:SecurityGroup", "load-balancer-security-group").Inputs, "egress")
+	if port := egress[0].ObjectValue()["fromPort"].NumberValue(); port != 3000 {
+		t.Errorf("load balancer egress port = %v, want 3000", port)
+	}
+}
+
+func TestInvalidHealthCheck(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.LoadBalancer.TargetGroup.HealthCheck = loadbalancer.HealthCheck{Timeout: 30, Interval: 30}
+
+	_, err := runWithMocks(t, configData)
+	if err == nil || !strings.Contains(err.Error(), "less than the interval") {
+		t.Fatalf("err = %v, want the health check timeout to be rejected", err)
+	}
+}
+
+func TestWAF(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.WAF = waf.WAF{
+		Enabled:           true,
+		RateLimit:         2000,
+		AllowedIPs:        []string{"10.0.0.0/8"},
+		BlockedIPs:        []string{"203.0.113.0/24", "2001:db8::/32"},
+		ManagedRuleGroups: []waf.ManagedRuleGroup{{Name: "AWSManagedRulesCommonRuleSet", CountRules: []string{"SizeRestrictions_BODY"}}, {Name: "AWSManagedRulesSQLiRuleSet", Count: true}},
+	}
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ruleNames []string
+	rules := arrayValue(m.find(t, "aws:wafv2/webAcl:WebAcl", "app-web-acl").Inputs, "rules")
+	for i, rule := range rules {
+		if priority := rule.ObjectValue()["priority"].NumberValue(); priority != float64(i) {
+			t.Errorf("rule %d has priority %v, want the rules in order", i, priority)
+		}
+
+		ruleNames = append(ruleNames, rule.ObjectValue()["name"].StringValue())
+	}
+
+	wantNames := []string{"allowed-ips-ipv4", "blocked-ips-ipv4", "blocked-ips-ipv6", "rate-limit", "AWSManagedRulesCommonRuleSet", "AWSManagedRulesSQLiRuleSet"}
+	if strings.Join(ruleNames, ",") != strings.Join(wantNames, ",") {
+		t.Errorf("web ACL rules = %v, want %v", ruleNames, wantNames)
+	}
+
+	if got := len(m.byType("aws:wafv2/ipSet:IpSet")); got != 3 {
+		t.Errorf("got %d IP sets, want one per list and IP version", got)
+	}
+
+	if limit := rules[3].ObjectValue()["statement"].ObjectValue()["rateBasedStatement"].ObjectValue()["limit"].NumberValue(); limit != 2000 {
+		t.Errorf("rate limit = %v, want 2000", limit)
+	}
+
+	if _, ok := rules[5].ObjectValue()["overrideAction"].ObjectValue()["count"]; !ok {
+		t.Errorf("SQLi rule group override = %v, want count", rules[5].ObjectValue()["overrideAction"])
+	}
+
+	logGroup := m.find(t, "aws:cloudwatch/logGroup:LogGroup", "app-web-acl-logs")
+	if name := stringInput(logGroup, "name"); name != "aws-waf-logs-iac-pulumi-test-use1-app" || logGroup.Inputs["retentionInDays"].NumberValue() != 30 {
+		t.Errorf("WAF log group %s kept for %v days, want aws-waf-logs-iac-pulumi-test-use1-app for 30 days", name, logGroup.Inputs["retentionInDays"])
+	}
+
+	wantLoadBalancerArn := fmt.Sprintf("arn:aws:lb:%s:%s:app-load-balancer", testRegion, testAccountID)
+	if got := stringInput(m.find(t, "aws:wafv2/webAclAssociation:WebAclAssociation", "app-web-acl-association"), "resourceArn"); got != wantLoadBalancerArn {
+		t.Errorf("web ACL is associated with %s, want %s", got, wantLoadBalancerArn)
+	}
+}
+
+func TestBlueGreen(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.LoadBalancer.TargetGroups = []loadbalancer.TargetGroup{{Name: "api", Port: 9090}}
+	configData.LoadBalancer.ListenerRules = []loadbalancer.ListenerRule{
+		{Name: "app", Priority: 10, Conditions: loadbalancer.RuleConditions{PathPatterns: []string{"/v1/*"}}, Action: loadbalancer.RuleAction{Type: loadbalancer.RuleActionForward}},
+	}
+	configData.BlueGreen = deployment.BlueGreen{Enabled: true, CanaryWeight: 10, Green: deployment.Fleet{AmiID: "ami-green"}}
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blueArn := fmt.Sprintf("arn:aws:lb:%s:%s:app-loadbalancer-tg", testRegion, testAccountID)
+	greenArn := fmt.Sprintf("arn:aws:lb:%s:%s:iac-pulumi-test-use1-green", testRegion, testAccountID)
+	apiArn := fmt.Sprintf("arn:aws:lb:%s:%s:iac-pulumi-test-use1-api", testRegion, testAccountID)
+
+	weights := func(forward resource.PropertyValue) map[string]float64 {
+		got := map[string]float64{}
+		for _, targetGroup := range arrayValue(forward.ObjectValue(), "targetGroups") {
+			got[targetGroup.ObjectValue()["arn"].StringValue()] = targetGroup.ObjectValue()["weight"].NumberValue()
+		}
+
+		return got
+	}
+
+	want := map[string]float64{blueArn: 90, greenArn: 10}
+	defaultAction := arrayValue(m.find(t, "aws:lb/listener:Listener", "frontEndListener").Inputs, "defaultActions")[0].ObjectValue()
+	if got := weights(defaultAction["forward"]); !reflect.DeepEqual(got, want) {
+		t.Errorf("listener forwards %v, want %v", got, want)
+	}
+
+	ruleAction := arrayValue(m.find(t, "aws:lb/listenerRule:ListenerRule", "listener-rule-app").Inputs, "actions")[0].ObjectValue()
+	if got := weights(ruleAction["forward"]); !reflect.DeepEqual(got, want) {
+		t.Errorf("rule forwarding to the app forwards %v, want %v", got, want)
+	}
+
+	// Blue keeps the resources of the single fleet, green gets its own and only the live fleet serves the api
+	for _, fleet := range []struct {
+		asg, launchTemplate, name, ami string
+		targetGroupArns                []string
+	}{
+		{asg: "example_auto_scaling_group", launchTemplate: "example_launch_template", name: "webapp-auto-scaling-group", ami: configData.EC2InstanceMetadata.AmiID, targetGroupArns: []string{blueArn, apiArn}},
+		{asg: "example_auto_scaling_group_green", launchTemplate: "example_launch_template_green", name: "iac-pulumi-test-use1-app-green", ami: "ami-green", targetGroupArns: []string{greenArn}},
+	} {
+		asg := m.find(t, "aws:autoscaling/group:Group", fleet.asg)
+		var targetGroupArns []string
+		for _, arn := range arrayValue(asg.Inputs, "targetGroupArns") {
+			targetGroupArns = append(targetGroupArns, arn.StringValue())
+		}
+
+		if stringInput(asg, "name") != fleet.name || !reflect.DeepEqual(targetGroupArns, fleet.targetGroupArns) {
+			t.Errorf("%s is %s attached to %v, want %s attached to %v", fleet.asg, stringInput(asg, "name"), targetGroupArns, fleet.name, fleet.targetGroupArns)
+		}
+
+		if ami := stringInput(m.find(t, "aws:ec2/launchTemplate:LaunchTemplate", fleet.launchTemplate), "imageId"); ami != fleet.ami {
+			t.Errorf("%s launches %s, want %s", fleet.launchTemplate, ami, fleet.ami)
+		}
+	}
+
+	for _, name := range []string{"target-tracking-cpu", "target-tracking-cpu_green"} {
+		m.find(t, "aws:autoscaling/policy:Policy", name)
+	}
+}
+
+func TestBlueGreenReservedTargetGroup(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.LoadBalancer.TargetGroups = []loadbalancer.TargetGroup{{Name: "green", Port: 9090}}
+	configData.BlueGreen.Enabled = true
+
+	_, err := runWithMocks(t, configData)
+	if err == nil || !strings.Contains(err.Error(), "used by the green fleet") {
+		t.Fatalf("err = %v, want the green target group name to be rejected", err)
+	}
+}
+
+func TestAutoScalingCapacity(t *testing.T) {
+	configData := testConfig(t, 2)
+	minSize, desiredCapacity := 2, 3
+	configData.AutoScaling = apptier.AutoScaling{
+		MinSize:             &minSize,
+		DesiredCapacity:     &desiredCapacity,
+		MaxSize:             6,
+		TerminationPolicies: []string{"OldestLaunchTemplate", "Default"},
+		CapacityRebalance:   true,
+		MaxInstanceLifetime: 604800,
+	}
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asg := m.find(t, "aws:autoscaling/group:Group", "example_auto_scaling_group").Inputs
+	for key, want := range map[string]interface{}{
+		"minSize":                2.0,
+		"desiredCapacity":        3.0,
+		"maxSize":                6.0,
+		"defaultCooldown":        60.0,
+		"healthCheckGracePeriod": 300.0,
+		"healthCheckType":        "ELB",
+		"capacityRebalance":      true,
+		"maxInstanceLifetime":    604800.0,
+	} {
+		if got := asg[resource.PropertyKey(key)].Mappable(); got != want {
+			t.Errorf("autoscaling group %s = %v, want %v", key, got, want)
+		}
+	}
+
+	if policies := arrayValue(asg, "terminationPolicies"); len(policies) != 2 || policies[0].StringValue() != "OldestLaunchTemplate" {
+		t.Errorf("termination policies = %v, want OldestLaunchTemplate then Default", policies)
+	}
+}
+
+func TestInvalidAutoScalingCapacity(t *testing.T) {
+	configData := testConfig(t, 2)
+	desiredCapacity := 5
+	configData.AutoScaling.DesiredCapacity = &desiredCapacity
+
+	_, err := runWithMocks(t, configData)
+	if err == nil || !strings.Contains(err.Error(), "desired_capacity 5") {
+		t.Fatalf("err = %v, want the desired capacity over the max size to be rejected", err)
+	}
+}
+
+func TestInstanceRefresh(t *testing.T) {
+	disabled := false
+	for _, tt := range []struct {
+		name    string
+		refresh apptier.InstanceRefresh
+		want    map[string]interface{}
+	}{
+		{
+			name: "defaults",
+			want: map[string]interface{}{"minHealthyPercentage": 90.0, "instanceWarmup": "300", "skipMatching": true},
+		},
+		{
+			name:    "checkpoints",
+			refresh: apptier.InstanceRefresh{CheckpointPercentages: []int{50, 100}, Triggers: []string{"tag"}},
+			want:    map[string]interface{}{"minHealthyPercentage": 90.0, "checkpointDelay": "3600", "skipMatching": true},
+		},
+		{
+			name:    "disabled",
+			refresh: apptier.InstanceRefresh{Enabled: &disabled},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := testConfig(t, 2)
+			configData.AutoScaling.InstanceRefresh = tt.refresh
+
+			m, err := runWithMocks(t, configData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			instanceRefresh := m.find(t, "aws:autoscaling/group:Group", "example_auto_scaling_group").Inputs["instanceRefresh"]
+			if tt.want == nil {
+				if !instanceRefresh.IsNull() {
+					t.Errorf("instance refresh = %v, want none", instanceRefresh)
+				}
+
+				return
+			}
+
+			if strategy := instanceRefresh.ObjectValue()["strategy"].StringValue(); strategy != "Rolling" {
+				t.Errorf("instance refresh strategy = %s, want Rolling", strategy)
+			}
+
+			preferences := instanceRefresh.ObjectValue()["preferences"].ObjectValue().Mappable()
+			for key, want := range tt.want {
+				if preferences[key] != want {
+					t.Errorf("instance refresh %s = %v, want %v", key, preferences[key], want)
+				}
+			}
+
+			if triggers := arrayValue(instanceRefresh.ObjectValue(), "triggers"); len(triggers) != len(tt.refresh.Triggers) {
+				t.Errorf("instance refresh triggers = %v, want %v", triggers, tt.refresh.Triggers)
+			}
+		})
+	}
+}
+
+func TestScalingPolicies(t *testing.T) {
+	configData := testConfig(t, 2)
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without policies in the config the group tracks 50% CPU and no alarms are created for it
+	policy := m.find(t, "aws:autoscaling/policy:Policy", "target-tracking-cpu").Inputs
+	configuration := policy["targetTrackingConfiguration"].ObjectValue().Mappable()
+	if policy["policyType"].StringValue() != "TargetTrackingScaling" || configuration["targetValue"] != 50.0 {
+		t.Errorf("default policy = %v, want target tracking at 50", policy)
+	}
+
+	for _, alarm := range m.byType("aws:cloudwatch/metricAlarm:MetricAlarm") {
+		if strings.HasPrefix(alarm.Name, "step-scaling-") || strings.HasPrefix(alarm.Name, "cpu_utilization_") {
+			t.Errorf("alarm %s was created for the default policy", alarm.Name)
+		}
+	}
+
+	lower, upper := 0.0, 20.0
+	configData.AutoScaling.Scaling = apptier.Scaling{
+		TargetTracking: []apptier.TargetTrackingPolicy{
+			{Name: "requests", Metric: apptier.Metric{Type: apptier.MetricALBRequestCount}, TargetValue: 1000},
+		},
+		StepScaling: []apptier.StepScalingPolicy{
+			{
+				Name:               "cpu-high",
+				Metric:             apptier.Metric{Type: apptier.MetricCPU},
+				ComparisonOperator: "GreaterThanOrEqualToThreshold",
+				Threshold:          70,
+				Steps: []apptier.StepAdjustment{
+					{LowerBound: &lower, UpperBound: &upper, Adjustment: 1},
+					{LowerBound: &upper, Adjustment: 2},
+				},
+			},
+		},
+	}
+
+	m, err = runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy = m.find(t, "aws:autoscaling/policy:Policy", "target-tracking-requests").Inputs
+	metric := policy["targetTrackingConfiguration"].ObjectValue()["predefinedMetricSpecification"].ObjectValue().Mappable()
+	wantLabel := "app/test/50dc6c495c0c9188/targetgroup/test/73e2d6bc24d8a067"
+	if metric["predefinedMetricType"] != "ALBRequestCountPerTarget" || metric["resourceLabel"] != wantLabel {
+		t.Errorf("request count metric = %v, want ALBRequestCountPerTarget of %s", metric, wantLabel)
+	}
+
+	stepPolicy := m.find(t, "aws:autoscaling/policy:Policy", "step-scaling-cpu-high")
+	steps := arrayValue(stepPolicy.Inputs, "stepAdjustments")
+	if len(steps) != 2 || steps[1].ObjectValue()["metricIntervalLowerBound"].StringValue() != "20" || !steps[1].ObjectValue()["metricIntervalUpperBound"].IsNull() {
+		t.Errorf("step adjustments = %v, want 0 to 20 and 20 and up", steps)
+	}
+
+	alarm := m.find(t, "aws:cloudwatch/metricAlarm:MetricAlarm", "step-scaling-cpu-high-alarm")
+	if stringInput(alarm, "name") != "iac-pulumi-test-use1-app-cpu-high" || stringInput(alarm, "metricName") != "CPUUtilization" {
+		t.Errorf("alarm is %s on %s, want iac-pulumi-test-use1-app-cpu-high on CPUUtilization", stringInput(alarm, "name"), stringInput(alarm, "metricName"))
+	}
+
+	if actions := arrayValue(alarm.Inputs, "alarmActions"); len(actions) != 1 {
+		t.Errorf("alarm actions = %v, want the step scaling policy", actions)
+	}
+
+	if policies := m.byType("aws:autoscaling/policy:Policy"); len(policies) != 2 {
+		t.Errorf("%d scaling policies were created, want only the 2 configured policies", len(policies))
+	}
+}
+
+func TestInvalidScalingPolicies(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.AutoScaling.Scaling.TargetTracking = []apptier.TargetTrackingPolicy{
+		{Name: "custom", Metric: apptier.Metric{Type: apptier.MetricCustom}, TargetValue: 10},
+	}
+
+	_, err := runWithMocks(t, configData)
+	if err == nil || !strings.Contains(err.Error(), "namespace") {
+		t.Fatalf("err = %v, want the custom metric without a namespace to be rejected", err)
+	}
+}
+
+func TestAccessLogs(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			configData := testConfig(t, 2)
+			configData.LoadBalancer.AccessLogs = loadbalancer.AccessLogs{Enabled: enabled, ExpirationDays: 30}
+
+			m, err := runWithMocks(t, configData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			alb := m.find(t, "aws:lb/loadBalancer:LoadBalancer", "test")
+			if !enabled {
+				if len(m.byType("aws:s3/bucketV2:BucketV2")) != 0 || !alb.Inputs["accessLogs"].IsNull() || !alb.Inputs["connectionLogs"].IsNull() {
+					t.Errorf("got an access logs bucket or logs %v %v, want none", alb.Inputs["accessLogs"], alb.Inputs["connectionLogs"])
+				}
+
+				return
+			}
+
+			bucketName := fmt.Sprintf("iac-pulumi-test-use1-alb-logs-%s", testAccountID)
+			if got := stringInput(m.find(t, "aws:s3/bucketV2:BucketV2", "alb-access-logs"), "bucket"); got != bucketName {
+				t.Errorf("access logs bucket = %s, want %s", got, bucketName)
+			}
+
+			accessLogs := alb.Inputs["accessLogs"].ObjectValue()
+			if accessLogs["bucket"].StringValue() != bucketName || accessLogs["prefix"].StringValue() != "alb" || !accessLogs["enabled"].BoolValue() {
+				t.Errorf("load balancer access logs = %v, want enabled to %s under alb", accessLogs, bucketName)
+			}
+
+			connectionLogs := alb.Inputs["connectionLogs"].ObjectValue()
+			if connectionLogs["bucket"].StringValue() != bucketName || connectionLogs["prefix"].StringValue() != "alb-connections" || !connectionLogs["enabled"].BoolValue() {
+				t.Errorf("load balancer connection logs = %v, want enabled to %s under alb-connections", connectionLogs, bucketName)
+			}
+
+			for _, rule := range arrayValue(m.find(t, "aws:s3/bucketLifecycleConfigurationV2:BucketLifecycleConfigurationV2", "alb-access-logs").Inputs, "rules") {
+				if days := rule.ObjectValue()["expiration"].ObjectValue()["days"].NumberValue(); days != 30 {
+					t.Errorf("%s expires after %v days, want 30", rule.ObjectValue()["id"].StringValue(), days)
+				}
+			}
+
+			encryption := arrayValue(m.find(t, "aws:s3/bucketServerSideEncryptionConfigurationV2:BucketServerSideEncryptionConfigurationV2", "alb-access-logs").Inputs, "rules")[0].ObjectValue()
+			if algorithm := encryption["applyServerSideEncryptionByDefault"].ObjectValue()["sseAlgorithm"].StringValue(); algorithm != "AES256" {
+				t.Errorf("access logs are encrypted with %s, want AES256", algorithm)
+			}
+
+			// us-east-1 delivers the logs from the ELB account of the region
+			policy := stringInput(m.find(t, "aws:s3/bucketPolicy:BucketPolicy", "alb-access-logs"), "policy")
+			if !strings.Contains(policy, "arn:aws:iam::127311923021:root") || !strings.Contains(policy, fmt.Sprintf("/alb/AWSLogs/%s/*", testAccountID)) ||
+				!strings.Contains(policy, fmt.Sprintf("/alb-connections/AWSLogs/%s/*", testAccountID)) {
+				t.Errorf("bucket policy = %s, want log delivery from the us-east-1 ELB account to the alb and alb-connections prefixes", policy)
+			}
+		})
+	}
+}
+
+func TestIAMPolicies(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.Kms = encryption.KMS{Enabled: true}
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var assumeRolePolicy struct {
+		Statement []struct {
+			Action    []string
+			Principal struct{ Service []string }
+		}
+	}
+
+	role := m.find(t, "aws:iam/role:Role", "ec2CloudWatchRole")
+	if err := json.Unmarshal([]byte(stringInput(role, "assumeRolePolicy")), &assumeRolePolicy); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(assumeRolePolicy.Statement) != 1 || assumeRolePolicy.Statement[0].Principal.Service[0] != "ec2.amazonaws.com" {
+		t.Errorf("assume role policy = %+v, want only ec2.amazonaws.com", assumeRolePolicy)
+	}
+
+	var snsPolicy struct {
+		Statement []struct {
+			Sid      string
+			Action   []string
+			Resource string
+		}
+	}
+
+	customSNSPolicy := m.find(t, "aws:iam/policy:Policy", "CustomSNSPolicy2")
+	if err := json.Unmarshal([]byte(stringInput(customSNSPolicy, "policy")), &snsPolicy); err != nil {
+		t.Fatal(err)
+	}
+
+	wantTopicArn := fmt.Sprintf("arn:aws:sns:%s:%s:%s", testRegion, testAccountID, configData.ResourceParams.SNSTopic)
+	if snsPolicy.Statement[0].Resource != wantTopicArn || strings.Join(snsPolicy.Statement[0].Action, ",") != "sns:Publish,sns:Subscribe" {
+		t.Errorf("sns publish statement = %+v, want publish and subscribe on %s", snsPolicy.Statement[0], wantTopicArn)
+	}
+
+	attachment := m.find(t, "aws:iam/rolePolicyAttachment:RolePolicyAttachment", "ec2SNSPolicy")
+	if got := stringInput(attachment, "role"); got != "ec2CloudWatchRole_id" {
+		t.Errorf("sns policy is attached to %s, want ec2CloudWatchRole_id", got)
+	}
+
+	keys := m.byType("aws:kms/key:Key")
+	if len(keys) != 1 {
+		t.Fatalf("got %d kms keys, want a single shared key", len(keys))
+	}
+
+	keyPolicy := stringInput(keys[0], "policy")
+	for _, want := range []string{
+		fmt.Sprintf("arn:aws:iam::%s:root", testAccountID),
+		fmt.Sprintf("arn:aws:iam:%s:%s:ec2CloudWatchRole", testRegion, testAccountID),
+		fmt.Sprintf("rds.%s.amazonaws.com", testRegion),
+		"AWSServiceRoleForAutoScaling",
+		fmt.Sprintf("logs.%s.amazonaws.com", testRegion),
+	} {
+		if !strings.Contains(keyPolicy, want) {
+			t.Errorf("kms key policy does not contain %q: %s", want, keyPolicy)
+		}
+	}
+}
+
+func TestNameTags(t *testing.T) {
+	configData := testConfig(t, 3)
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		typeToken string
+		name      string
+		wantName  string
+	}{
+		{"aws:ec2/vpc:Vpc", configData.Vpc, configData.Vpc},
+		{"aws:ec2/internetGateway:InternetGateway", configData.InternetGateway, configData.InternetGateway},
+		{"aws:ec2/routeTable:RouteTable", configData.PublicRouteTable, configData.PublicRouteTable},
+		{"aws:ec2/routeTable:RouteTable", configData.PrivateRouteTable, configData.PrivateRouteTable},
+		{"aws:ec2/subnet:Subnet", configData.PublicSubnetsPrefix + "-2", configData.PublicSubnetsPrefix + "-2"},
+		{"aws:ec2/subnet:Subnet", configData.PrivateSubnetPrefix + "-0", configData.PrivateSubnetPrefix + "-0"},
+		{"aws:ec2/securityGroup:SecurityGroup", configData.SecurityGroup, configData.SecurityGroup},
+		{"aws:ec2/securityGroup:SecurityGroup", configData.RDSInstanceMetadata.SecurityGroupName, "database-security-group"},
+		{"aws:ec2/launchTemplate:LaunchTemplate", "example_launch_template", configData.EC2InstanceMetadata.InstanceName},
+		{"aws:lb/loadBalancer:LoadBalancer", "test", "app-load-balancer"},
+		{"aws:iam/role:Role", "ec2CloudWatchRole", "iac-pulumi-test-ec2CloudWatchRole"},
+	}
+
+	for _, tt := range tests {
+		if got := tagValue(m.find(t, tt.typeToken, tt.name), "Name"); got != tt.wantName {
+			t.Errorf("%s %s Name tag = %q, want %q", tt.typeToken, tt.name, got, tt.wantName)
+		}
+	}
+}
+
+func TestMandatoryTags(t *testing.T) {
+	configData := testConfig(t, 2)
+
+	m, err := runWithMocks(t, configData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantTags := map[string]string{
+		"environment": "test",
+		"owner":       "platform-team",
+		"cost-center": "cc-1234",
+		"team":        "webapp",
+		"stack":       "test",
+		"project":     "iac-pulumi",
+	}
+
+	tagged := 0
+	for _, res := range m.resources {
+		// The auto scaling group tags are a list, checked below
+		if tags, ok := res.Inputs["tags"]; !ok || !tags.IsObject() {
+			continue
+		}
+
+		tagged++
+		for key, want := range wantTags {
+			if got := tagValue(res, key); got != want {
+				t.Errorf("%s %s tag %s = %q, want %q", res.Type, res.Name, key, got, want)
+			}
+		}
+
+		if tagValue(res, "Name") == "" {
+			t.Errorf("%s %s has no Name tag", res.Type, res.Name)
+		}
+	}
+
+	// Everything taggable is tagged, including resources that had no tags at all before
+	if want := len(m.byType("aws:ec2/subnet:Subnet")) + 10; tagged < want {
+		t.Errorf("got %d tagged resources, want at least %d", tagged, want)
+	}
+
+	// The auto scaling group tags propagate to the instances it launches
+	groupTags := map[string]string{}
+	for _, tag := range arrayValue(m.find(t, "aws:autoscaling/group:Group", "example_auto_scaling_group").Inputs, "tags") {
+		object := tag.ObjectValue()
+		if !object["propagateAtLaunch"].BoolValue() {
+			t.Errorf("auto scaling group tag %v doesn't propagate at launch", object["key"])
+		}
+
+		groupTags[object["key"].StringValue()] = object["value"].StringValue()
+	}
+
+	for key, want := range wantTags {
+		if got := groupTags[key]; got != want {
+			t.Errorf("auto scaling group tag %s = %q, want %q", key, got, want)
+		}
+	}
+
+	if got := groupTags["Name"]; got != configData.EC2InstanceMetadata.InstanceName {
+		t.Errorf("auto scaling group Name tag = %q, want %q", got, configData.EC2InstanceMetadata.InstanceName)
+	}
+}
+
+func TestMissingMandatoryTags(t *testing.T) {
+	configData := testConfig(t, 2)
+	configData.Tags.Owner = ""
+
+	if _, err := runWithMocks(t, configData); err == nil || !strings.Contains(err.Error(), "owner") {
+		t.Errorf("got error %v, want the missing owner tag", err)
+	}
+}
+
+func TestPhysicalNames(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("naming enabled %t", enabled), func(t *testing.T) {
+			configData := testConfig(t, 2)
+			configData.Naming.Enabled = enabled
+			configData.LoadBalancer.TargetGroups = []loadbalancer.TargetGroup{{Name: "api", Port: 9090}}
+
+			m, err := runWithMocks(t, configData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Resources that existed before the naming scheme keep their names unless it is enabled
+			tests := []struct {
+				typeToken string
+				name      string
+				key       string
+				existing  string
+				want      string
+			}{
+				{"aws:lb/loadBalancer:LoadBalancer", "test", "name", "app-load-balancer", "iac-pulumi-test-use1-app"},
+				{"aws:lb/targetGroup:TargetGroup", "test", "name", "app-loadbalancer-tg", "iac-pulumi-test-use1-app"},
+				{"aws:ec2/launchTemplate:LaunchTemplate", "example_launch_template", "name", "asg_launch_config", "iac-pulumi-test-use1-app"},
+				{"aws:autoscaling/group:Group", "example_auto_scaling_group", "name", "webapp-auto-scaling-group", "iac-pulumi-test-use1-app"},
+				{"aws:ec2/keyPair:KeyPair", configData.EC2InstanceMetadata.SSHKeyName, "keyName", "webapp-key", "iac-pulumi-test-use1-webapp-key"},
+				{"aws:sns/topic:Topic", "testSNSTopic", "name", "submissions", "iac-pulumi-test-use1-submissions"},
+				{"aws:rds/instance:Instance", configData.RDSInstanceMetadata.InstanceName, "identifier", "webapp-db", "iac-pulumi-test-use1-webapp-db"},
+				{"aws:rds/parameterGroup:ParameterGroup", "webapp-parameter-group", "name", "webapp-rds-parameter-group", "iac-pulumi-test-use1-rds-postgres15"},
+				// The subnet group was named by Pulumi, it keeps the generated name
+				{"aws:rds/subnetGroup:SubnetGroup", configData.RDSInstanceMetadata.SubnetGrp, "name", "", "iac-pulumi-test-use1-webapp-db-subnet-group"},
+			}
+
+			for _, tt := range tests {
+				want := tt.existing
+				if enabled {
+					want = tt.want
+				}
+
+				if got := stringInput(m.find(t, tt.typeToken, tt.name), tt.key); got != want {
+					t.Errorf("%s %s %s = %q, want %q", tt.typeToken, tt.name, tt.key, got, want)
+				}
+			}
+
+			// Resources added since, and the RDS alarms that are on by default, always use the naming scheme
+			always := []struct {
+				typeToken string
+				name      string
+				want      string
+			}{
+				{"aws:lb/targetGroup:TargetGroup", "api-target-group", "iac-pulumi-test-use1-api"},
+				{"aws:sns/topic:Topic", "rdsAlertTopic", "iac-pulumi-test-use1-rds-alerts"},
+				{"aws:cloudwatch/metricAlarm:MetricAlarm", "rds-cpu-high", "iac-pulumi-test-use1-rds-cpu-high"},
+			}
+
+			for _, tt := range always {
+				if got := stringInput(m.find(t, tt.typeToken, tt.name), "name"); got != tt.want {
+					t.Errorf("%s %s name = %q, want %q", tt.typeToken, tt.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUserData(t *testing.T) {
+	tests := []struct {
+		name          string
+		mutate        func(configData *Data)
+		wantHost      string
+		wantReadHosts string
+	}{
+		{
+			name:     "single instance",
+			mutate:   func(configData *Data) {},
+			wantHost: "webapp-db.rds.amazonaws.com",
+		},
+		{
+			name: "instance with read replicas",
+			mutate: func(configData *Data) {
+				configData.RDSInstanceMetadata.ReadReplicas = []database.RDSReadReplica{{}, {}}
+			},
+			wantHost:      "webapp-db.rds.amazonaws.com",
+			wantReadHosts: "webapp-db-replica-0.rds.amazonaws.com,webapp-db-replica-1.rds.amazonaws.com",
+		},
+		{
+			name: "aurora cluster with readers",
+			mutate: func(configData *Data) {
+				configData.RDSInstanceMetadata.Mode = database.RDSModeAurora
+				configData.RDSInstanceMetadata.EngineVersion = "15.4"
+				configData.RDSInstanceMetadata.Aurora = database.AuroraCluster{ReaderCount: 2}
+			},
+			wantHost:      "webapp-db-cluster.cluster.rds.amazonaws.com",
+			wantReadHosts: "webapp-db-cluster.cluster-ro.rds.amazonaws.com",
+		},
+		{
+			name: "instance behind a proxy",
+			mutate: func(configData *Data) {
+				configData.RDSInstanceMetadata.Proxy = database.RDSProxy{Enabled: true}
+			},
+			wantHost: "webapp-db-proxy.proxy.rds.amazonaws.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := testConfig(t, 2)
+			tt.mutate(&configData)
+
+			m, err := runWithMocks(t, configData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			launchTemplate := m.find(t, "aws:ec2/launchTemplate:LaunchTemplate", "example_launch_template")
+			userData, err := base64.StdEncoding.DecodeString(stringInput(launchTemplate, "userData"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range []string{
+				"#!/bin/bash\n",
+				`sudo echo "PORT=8080"`,
+				`sudo echo "DB_USER=webapp"`,
+				`sudo echo "DB_PASS=s3cr3t-password"`,
+				fmt.Sprintf(`sudo echo "DB_HOST='%s'"`, tt.wantHost),
+				fmt.Sprintf(`sudo echo "DB_READ_HOSTS='%s'"`, tt.wantReadHosts),
+				`sudo echo "DB_PORT=5432"`,
+				`sudo echo "METRIC_SERVER_PORT=8125"`,
+				`sudo echo "MAILGUN_DOMAIN='mg.example.com'"`,
+				fmt.Sprintf(`sudo echo "TOPIC_ARN=arn:aws:sns:%s:%s:%s"`, testRegion, testAccountID, configData.ResourceParams.SNSTopic),
+				"sudo systemctl restart amazon-cloudwatch-agent",
+			} {
+				if !strings.Contains(string(userData), want) {
+					t.Errorf("user data does not contain %q:\n%s", want, userData)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type IP struct {
+	Origin string `json:"origin"`
+}
+
+func getPublicIPV4(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("couldn't fetch public IP address")
+	}
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var myIp IP
+	if err = json.Unmarshal(body, &myIp); err != nil {
+		return "", err
+	}
+
+	if myIp.Origin == "" {
+		return "", errors.New("ip address not found")
+	}
+
+	return myIp.Origin, nil
+}